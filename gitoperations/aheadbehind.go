@@ -0,0 +1,170 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// AheadBehindStatus tells whether AheadBehind could count a ref's commits.
+type AheadBehindStatus int
+
+const (
+	// AheadBehindCounted means Ahead and Behind compare the ref with Base.
+	AheadBehindCounted AheadBehindStatus = iota
+	// AheadBehindUpstreamGone means the ref's upstream is configured but no longer exists, as happens once a branch
+	// deleted from the remote is pruned.
+	AheadBehindUpstreamGone
+	// AheadBehindNoUpstream means the ref has no upstream configured.
+	AheadBehindNoUpstream
+)
+
+// AheadBehindCount compares a ref with a base.  Ahead is the number of commits reachable from Ref but not from Base,
+// and Behind the number reachable from Base but not from Ref.
+type AheadBehindCount struct {
+	// Ref is the full name of the ref.
+	Ref string
+	// Base is the base given to AheadBehind, or the full name of the ref's upstream, which is empty when it has none.
+	Base   string
+	Ahead  int
+	Behind int
+	Status AheadBehindStatus
+}
+
+// The minimum git version which supports the ahead-behind atom of 'git for-each-ref --format'.
+const aheadBehindAtomMajor, aheadBehindAtomMinor = 2, 41
+
+func AheadBehind(exec Executor, base string, refs ...string) ([]AheadBehindCount, error) {
+	// Compares each ref matching refs, patterns as for for-each-ref such as "refs/heads/main" or "refs/heads/", with
+	// base, or with the ref's upstream when base is empty.  Without refs every local branch is compared.  Refs which
+	// do not exist are left out, and the rest are returned in name order.
+	if err := checkArguments(refs...); err != nil {
+		return nil, err
+	}
+	if len(refs) == 0 {
+		refs = []string{"refs/heads/"}
+	}
+	if len(base) == 0 {
+		return aheadBehindUpstreams(exec, refs)
+	}
+	if err := checkArguments(base); err != nil {
+		return nil, err
+	}
+	version, err := GetGitVersion(exec)
+	if err != nil {
+		return nil, err
+	}
+	// The atom's argument ends at the first closing parenthesis, which ref names may contain.
+	if !version.AtLeast(aheadBehindAtomMajor, aheadBehindAtomMinor) || strings.Contains(base, ")") {
+		return aheadBehindByRevList(exec, base, refs)
+	}
+	// Each ref is reported as:
+	// <ref> NUL <ahead> SP <behind> LF
+	cmdArr := append([]string{"git", "for-each-ref", "--format=%(refname)%00%(ahead-behind:" + base + ")", "--"},
+		refs...)
+	out, err := runAndGetOutput(exec, cmdArr)
+	if err != nil {
+		return nil, err
+	}
+	counts := []AheadBehindCount{}
+	for _, line := range strings.Split(strings.TrimSuffix(string(out), "\n"), "\n") {
+		if len(line) == 0 {
+			continue
+		}
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 2 {
+			return nil, errors.New("Unrecognized for-each-ref output: " + line)
+		}
+		count := AheadBehindCount{Ref: fields[0], Base: base}
+		if _, err := fmt.Sscanf(fields[1], "%d %d", &count.Ahead, &count.Behind); err != nil {
+			return nil, errors.New("Unrecognized for-each-ref output: " + line)
+		}
+		counts = append(counts, count)
+	}
+	return counts, nil
+}
+
+// aheadBehindByRevList compares refs with base one at a time, for git older than 2.41 or bases the ahead-behind atom
+// cannot take.
+func aheadBehindByRevList(exec Executor, base string, refs []string) ([]AheadBehindCount, error) {
+	cmdArr := append([]string{"git", "for-each-ref", "--format=%(refname)", "--"}, refs...)
+	out, err := runAndGetOutput(exec, cmdArr)
+	if err != nil {
+		return nil, err
+	}
+	counts := []AheadBehindCount{}
+	for _, ref := range strings.Split(string(out), "\n") {
+		if len(ref) == 0 {
+			continue
+		}
+		// The symmetric difference counts commits only reachable from base on the left, and from ref on the right:
+		// <behind> TAB <ahead> LF
+		cmdArr := []string{"git", "rev-list", "--left-right", "--count", "--end-of-options", base + "..." + ref, "--"}
+		out, err := runAndGetOutput(exec, cmdArr)
+		if err != nil {
+			return nil, err
+		}
+		count := AheadBehindCount{Ref: ref, Base: base}
+		if _, err := fmt.Sscanf(string(out), "%d\t%d", &count.Behind, &count.Ahead); err != nil {
+			return nil, errors.New("Unrecognized rev-list output: " + string(out))
+		}
+		counts = append(counts, count)
+	}
+	return counts, nil
+}
+
+// aheadBehindUpstreams compares refs with their upstreams.
+func aheadBehindUpstreams(exec Executor, refs []string) ([]AheadBehindCount, error) {
+	// Each ref is reported as:
+	// <ref> NUL <upstream> NUL [gone|ahead <n>|behind <n>|ahead <n>, behind <n>] LF
+	// where the upstream is empty when none is configured, and the counts are empty when the ref and its upstream
+	// are the same.
+	cmdArr := append([]string{"git", "for-each-ref",
+		"--format=%(refname)%00%(upstream)%00%(upstream:track,nobracket)", "--"}, refs...)
+	out, err := runAndGetOutput(exec, cmdArr)
+	if err != nil {
+		return nil, err
+	}
+	counts := []AheadBehindCount{}
+	for _, line := range strings.Split(strings.TrimSuffix(string(out), "\n"), "\n") {
+		if len(line) == 0 {
+			continue
+		}
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 3 {
+			return nil, errors.New("Unrecognized for-each-ref output: " + line)
+		}
+		count := AheadBehindCount{Ref: fields[0], Base: fields[1]}
+		switch {
+		case len(count.Base) == 0:
+			count.Status = AheadBehindNoUpstream
+		case fields[2] == "gone":
+			count.Status = AheadBehindUpstreamGone
+		case len(fields[2]) != 0:
+			for _, part := range strings.Split(fields[2], ", ") {
+				words := strings.Split(part, " ")
+				if len(words) != 2 {
+					return nil, errors.New("Unrecognized for-each-ref output: " + line)
+				}
+				n, err := strconv.Atoi(words[1])
+				if err != nil {
+					return nil, errors.New("Unrecognized for-each-ref output: " + line)
+				}
+				switch words[0] {
+				case "ahead":
+					count.Ahead = n
+				case "behind":
+					count.Behind = n
+				default:
+					return nil, errors.New("Unrecognized for-each-ref output: " + line)
+				}
+			}
+		}
+		counts = append(counts, count)
+	}
+	return counts, nil
+}
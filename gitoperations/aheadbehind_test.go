@@ -0,0 +1,100 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestAheadBehind(t *testing.T) {
+	setup()
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{stdout: "git version 2.41.0\n"},
+			fakeResponse{stdout: "refs/heads/main\x000 0\nrefs/heads/topic\x003 12\n"})
+		counts, err := AheadBehind(mockGit, "origin/main", "refs/heads/")
+		if err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		expected := []AheadBehindCount{
+			{Ref: "refs/heads/main", Base: "origin/main"},
+			{Ref: "refs/heads/topic", Base: "origin/main", Ahead: 3, Behind: 12},
+		}
+		if !reflect.DeepEqual(counts, expected) {
+			t.Fatalf("Expected %+v, but received %+v", expected, counts)
+		}
+		expectedCmd := []string{"git", "for-each-ref", "--format=%(refname)%00%(ahead-behind:origin/main)", "--",
+			"refs/heads/"}
+		if !reflect.DeepEqual(calls[1], expectedCmd) {
+			t.Fatalf("Expected %v, but received %v", expectedCmd, calls[1])
+		}
+	}
+	{ // Older versions of git count each ref with rev-list.
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{stdout: "git version 2.39.5\n"},
+			fakeResponse{stdout: "refs/heads/topic\nrefs/tags/v1\n"},
+			fakeResponse{stdout: "12\t3\n"},
+			fakeResponse{stdout: "0\t7\n"})
+		counts, err := AheadBehind(mockGit, "origin/main", "refs/heads/topic", "refs/tags/v1")
+		if err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		expected := []AheadBehindCount{
+			{Ref: "refs/heads/topic", Base: "origin/main", Ahead: 3, Behind: 12},
+			{Ref: "refs/tags/v1", Base: "origin/main", Ahead: 7},
+		}
+		if !reflect.DeepEqual(counts, expected) {
+			t.Fatalf("Expected %+v, but received %+v", expected, counts)
+		}
+		expectedCmds := [][]string{
+			{"git", "version"},
+			{"git", "for-each-ref", "--format=%(refname)", "--", "refs/heads/topic", "refs/tags/v1"},
+			{"git", "rev-list", "--left-right", "--count", "--end-of-options", "origin/main...refs/heads/topic", "--"},
+			{"git", "rev-list", "--left-right", "--count", "--end-of-options", "origin/main...refs/tags/v1", "--"},
+		}
+		if !reflect.DeepEqual(calls, expectedCmds) {
+			t.Fatalf("Expected %v, but received %v", expectedCmds, calls)
+		}
+	}
+	{ // Without a base each local branch is compared with its upstream.
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{stdout: "" +
+			"refs/heads/a\x00refs/remotes/origin/a\x00\n" +
+			"refs/heads/b\x00refs/remotes/origin/b\x00ahead 1, behind 2\n" +
+			"refs/heads/c\x00refs/remotes/origin/c\x00gone\n" +
+			"refs/heads/d\x00\x00\n" +
+			"refs/heads/e\x00refs/heads/main\x00behind 4\n"})
+		counts, err := AheadBehind(mockGit, "")
+		if err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		expected := []AheadBehindCount{
+			{Ref: "refs/heads/a", Base: "refs/remotes/origin/a"},
+			{Ref: "refs/heads/b", Base: "refs/remotes/origin/b", Ahead: 1, Behind: 2},
+			{Ref: "refs/heads/c", Base: "refs/remotes/origin/c", Status: AheadBehindUpstreamGone},
+			{Ref: "refs/heads/d", Status: AheadBehindNoUpstream},
+			{Ref: "refs/heads/e", Base: "refs/heads/main", Behind: 4},
+		}
+		if !reflect.DeepEqual(counts, expected) {
+			t.Fatalf("Expected %+v, but received %+v", expected, counts)
+		}
+		expectedCmd := []string{"git", "for-each-ref",
+			"--format=%(refname)%00%(upstream)%00%(upstream:track,nobracket)", "--", "refs/heads/"}
+		if !reflect.DeepEqual(calls, [][]string{expectedCmd}) {
+			t.Fatalf("Expected %v, but received %v", expectedCmd, calls)
+		}
+	}
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{stdout: "refs/heads/a\x00refs/heads/b\x00diverged\n"})
+		if _, err := AheadBehind(mockGit, ""); err == nil {
+			t.Fatalf("Expected non-nil error")
+		}
+		if _, err := AheadBehind(mockGit, "--all"); !errors.Is(err, ErrUnsafeArgument) {
+			t.Fatalf("Expected ErrUnsafeArgument, but received %v", err)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNoForkPoint is returned by ForkPoint when the upstream's reflog holds no ancestor of the ref.
+var ErrNoForkPoint = errors.New("No fork point found")
+
+func IsAncestor(exec Executor, ancestor string, descendant string) (bool, error) {
+	// Reports whether ancestor is reachable from descendant.  A commit is its own ancestor.  merge-base exits 0 when
+	// it is, 1 when it is not, and 128 when either revision is invalid.
+	if err := checkArguments(ancestor, descendant); err != nil {
+		return false, err
+	}
+	cmdArr := []string{"git", "merge-base", "--is-ancestor", "--end-of-options", ancestor, descendant}
+	_, err := runAndGetOutput(exec, cmdArr)
+	if err == nil {
+		return true, nil
+	}
+	if exitCodeOf(err) == 1 && noStderr(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+func MergeBases(exec Executor, revisions []string, octopus bool) ([]ObjectID, error) {
+	// Returns every best common ancestor of the first revision and any of the others, or with octopus of all of the
+	// revisions together, as for an n-way merge.  Histories with criss-cross merges have several.  The list is empty
+	// when the revisions share no history, which merge-base reports by exiting 1 without output.
+	if err := checkArguments(revisions...); err != nil {
+		return nil, err
+	}
+	cmdArr := []string{"git", "merge-base", "--all"}
+	if octopus {
+		cmdArr = append(cmdArr, "--octopus")
+	}
+	cmdArr = append(cmdArr, "--end-of-options")
+	cmdArr = append(cmdArr, revisions...)
+	out, err := runAndGetOutput(exec, cmdArr)
+	if err != nil {
+		if exitCodeOf(err) == 1 && noStderr(err) {
+			return []ObjectID{}, nil
+		}
+		return nil, err
+	}
+	return parseObjectIDLines(out)
+}
+
+func MergeBase(exec Executor, revision string, other string) (ObjectID, error) {
+	// Returns a best common ancestor of revision and other, the one git chooses when histories with criss-cross merges
+	// have several.  The id is empty when the revisions share no history, which merge-base reports by exiting 1
+	// without output.
+	if err := checkArguments(revision, other); err != nil {
+		return "", err
+	}
+	cmdArr := []string{"git", "merge-base", "--end-of-options", revision, other}
+	out, err := runAndGetOutput(exec, cmdArr)
+	if err != nil {
+		if exitCodeOf(err) == 1 && noStderr(err) {
+			return "", nil
+		}
+		return "", err
+	}
+	return ParseObjectID(string(out))
+}
+
+func ForkPoint(exec Executor, ref string, upstream string) (ObjectID, error) {
+	// Returns the commit at which ref forked from upstream, using upstream's reflog to find where ref branched off even
+	// when upstream has since been rewound or rebased.  Returns ErrNoForkPoint when merge-base exits 1 without output.
+	if err := checkArguments(ref, upstream); err != nil {
+		return "", err
+	}
+	cmdArr := []string{"git", "merge-base", "--fork-point", "--end-of-options", upstream, ref}
+	out, err := runAndGetOutput(exec, cmdArr)
+	if err != nil {
+		if exitCodeOf(err) == 1 && noStderr(err) {
+			return "", fmt.Errorf("%w: %q from %q", ErrNoForkPoint, ref, upstream)
+		}
+		return "", err
+	}
+	return ParseObjectID(string(out))
+}
+
+func BranchesContaining(exec Executor, commit string, remotes bool) ([]string, error) {
+	// Returns the full names of the local branches, and with remotes also the remote-tracking branches, whose history
+	// includes commit.
+	patterns := []string{"refs/heads/"}
+	if remotes {
+		patterns = append(patterns, "refs/remotes/")
+	}
+	return refsContaining(exec, commit, patterns)
+}
+
+func TagsContaining(exec Executor, commit string) ([]string, error) {
+	// Returns the full names of the tags whose history includes commit, such as the releases which shipped it.
+	return refsContaining(exec, commit, []string{"refs/tags/"})
+}
+
+// refsContaining lists the refs matching patterns which can reach commit.
+func refsContaining(exec Executor, commit string, patterns []string) ([]string, error) {
+	if err := checkArguments(commit); err != nil {
+		return nil, err
+	}
+	cmdArr := append([]string{"git", "for-each-ref", "--format=%(refname)", "--contains=" + commit, "--"}, patterns...)
+	out, err := runAndGetOutput(exec, cmdArr)
+	if err != nil {
+		return nil, err
+	}
+	refs := []string{}
+	for _, line := range strings.Split(string(out), "\n") {
+		if len(line) != 0 {
+			refs = append(refs, line)
+		}
+	}
+	return refs, nil
+}
+
+// parseObjectIDLines parses output listing an object id on each line.
+func parseObjectIDLines(out []byte) ([]ObjectID, error) {
+	ids := []ObjectID{}
+	for _, line := range strings.Split(string(out), "\n") {
+		if len(line) == 0 {
+			continue
+		}
+		id, err := ParseObjectID(line)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// noStderr reports whether err is a GitError for a command which printed nothing to stderr, as commands do when their
+// exit status is an answer rather than a failure.
+func noStderr(err error) bool {
+	var gitErr *GitError
+	return errors.As(err, &gitErr) && len(strings.TrimSpace(gitErr.Stderr)) == 0
+}
@@ -0,0 +1,132 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestIsAncestor(t *testing.T) {
+	setup()
+	calls := [][]string{}
+	isAncestor, err := IsAncestor(createScriptedExecCommand(&calls, fakeResponse{}), "main", "topic")
+	if err != nil || !isAncestor {
+		t.Fatalf("Expected true, but received %v, %v", isAncestor, err)
+	}
+	expectedCmd := []string{"git", "merge-base", "--is-ancestor", "--end-of-options", "main", "topic"}
+	if !reflect.DeepEqual(calls[0], expectedCmd) {
+		t.Fatalf("Expected %v, but received %v", expectedCmd, calls[0])
+	}
+	isAncestor, err = IsAncestor(createScriptedExecCommand(&calls, fakeResponse{exitStatus: 1}), "topic", "main")
+	if err != nil || isAncestor {
+		t.Fatalf("Expected false, but received %v, %v", isAncestor, err)
+	}
+	mockGit := createScriptedExecCommand(&calls,
+		fakeResponse{stderr: "fatal: Not a valid object name nope\n", exitStatus: 128})
+	if _, err := IsAncestor(mockGit, "nope", "main"); exitCodeOf(err) != 128 {
+		t.Fatalf("Expected exit status 128, but received %v", err)
+	}
+	if _, err := IsAncestor(mockGit, "main", "--all"); !errors.Is(err, ErrUnsafeArgument) {
+		t.Fatalf("Expected ErrUnsafeArgument, but received %v", err)
+	}
+}
+
+func TestMergeBases(t *testing.T) {
+	setup()
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{stdout: testSHA1 + "\n" + testSHA256 + "\n"})
+		bases, err := MergeBases(mockGit, []string{"main", "topic", "other"}, true)
+		if err != nil || !reflect.DeepEqual(bases, []ObjectID{testSHA1, testSHA256}) {
+			t.Fatalf("Expected both merge bases, but received %v, %v", bases, err)
+		}
+		expectedCmd := []string{"git", "merge-base", "--all", "--octopus", "--end-of-options", "main", "topic", "other"}
+		if !reflect.DeepEqual(calls[0], expectedCmd) {
+			t.Fatalf("Expected %v, but received %v", expectedCmd, calls[0])
+		}
+	}
+	{ // Unrelated histories have no merge base.
+		calls := [][]string{}
+		bases, err := MergeBases(createScriptedExecCommand(&calls, fakeResponse{exitStatus: 1}),
+			[]string{"main", "orphan"}, false)
+		if err != nil || len(bases) != 0 {
+			t.Fatalf("Expected no merge bases, but received %v, %v", bases, err)
+		}
+		expectedCmd := []string{"git", "merge-base", "--all", "--end-of-options", "main", "orphan"}
+		if !reflect.DeepEqual(calls[0], expectedCmd) {
+			t.Fatalf("Expected %v, but received %v", expectedCmd, calls[0])
+		}
+	}
+}
+
+func TestMergeBase(t *testing.T) {
+	setup()
+	{
+		calls := [][]string{}
+		id, err := MergeBase(createScriptedExecCommand(&calls, fakeResponse{stdout: testSHA256 + "\n"}), "main", "topic")
+		if err != nil || id != testSHA256 {
+			t.Fatalf("Expected %s, but received %q, %v", testSHA256, id, err)
+		}
+		expectedCmd := []string{"git", "merge-base", "--end-of-options", "main", "topic"}
+		if !reflect.DeepEqual(calls[0], expectedCmd) {
+			t.Fatalf("Expected %v, but received %v", expectedCmd, calls[0])
+		}
+	}
+	{ // Unrelated histories have no merge base, while other failures are errors.
+		calls := [][]string{}
+		if id, err := MergeBase(createScriptedExecCommand(&calls, fakeResponse{exitStatus: 1}), "main",
+			"orphan"); err != nil || len(id) != 0 {
+			t.Fatalf("Expected no merge base, but received %q, %v", id, err)
+		}
+		mockGit := createScriptedExecCommand(&calls,
+			fakeResponse{stderr: "fatal: Not a valid object name nope\n", exitStatus: 128})
+		if _, err := MergeBase(mockGit, "main", "nope"); exitCodeOf(err) != 128 {
+			t.Fatalf("Expected exit status 128, but received %v", err)
+		}
+	}
+}
+
+func TestForkPoint(t *testing.T) {
+	setup()
+	calls := [][]string{}
+	id, err := ForkPoint(createScriptedExecCommand(&calls, fakeResponse{stdout: testSHA1 + "\n"}), "topic", "origin/main")
+	if err != nil || id != testSHA1 {
+		t.Fatalf("Expected %s, but received %q, %v", testSHA1, id, err)
+	}
+	expectedCmd := []string{"git", "merge-base", "--fork-point", "--end-of-options", "origin/main", "topic"}
+	if !reflect.DeepEqual(calls[0], expectedCmd) {
+		t.Fatalf("Expected %v, but received %v", expectedCmd, calls[0])
+	}
+	_, err = ForkPoint(createScriptedExecCommand(&calls, fakeResponse{exitStatus: 1}), "topic", "origin/main")
+	if !errors.Is(err, ErrNoForkPoint) {
+		t.Fatalf("Expected ErrNoForkPoint, but received %v", err)
+	}
+}
+
+func TestBranchesAndTagsContaining(t *testing.T) {
+	setup()
+	calls := [][]string{}
+	mockGit := createScriptedExecCommand(&calls, fakeResponse{stdout: "refs/heads/main\nrefs/remotes/origin/main\n"},
+		fakeResponse{stdout: ""})
+	branches, err := BranchesContaining(mockGit, testSHA1, true)
+	if err != nil || !reflect.DeepEqual(branches, []string{"refs/heads/main", "refs/remotes/origin/main"}) {
+		t.Fatalf("Expected both branches, but received %v, %v", branches, err)
+	}
+	tags, err := TagsContaining(mockGit, testSHA1)
+	if err != nil || len(tags) != 0 {
+		t.Fatalf("Expected no tags, but received %v, %v", tags, err)
+	}
+	expectedCmds := [][]string{
+		{"git", "for-each-ref", "--format=%(refname)", "--contains=" + testSHA1, "--", "refs/heads/", "refs/remotes/"},
+		{"git", "for-each-ref", "--format=%(refname)", "--contains=" + testSHA1, "--", "refs/tags/"},
+	}
+	if !reflect.DeepEqual(calls, expectedCmds) {
+		t.Fatalf("Expected %v, but received %v", expectedCmds, calls)
+	}
+	if _, err := TagsContaining(mockGit, "-x"); !errors.Is(err, ErrUnsafeArgument) {
+		t.Fatalf("Expected ErrUnsafeArgument, but received %v", err)
+	}
+}
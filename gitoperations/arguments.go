@@ -0,0 +1,53 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnsafeArgument is returned, wrapped, when a caller supplied ref, revision or name could be mistaken by git for a
+// command line option.
+var ErrUnsafeArgument = errors.New("Argument could be interpreted as an option")
+
+// ErrInvalidRefName is returned, wrapped, by ValidateBranchName and ValidateRefName for names git rejects.
+var ErrInvalidRefName = errors.New("Invalid ref name")
+
+// checkArguments rejects arguments which git would parse as options.  Commands are additionally given
+// --end-of-options or --, but checking up front also protects commands and git versions which lack those.
+func checkArguments(args ...string) error {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			return fmt.Errorf("%w: %q", ErrUnsafeArgument, arg)
+		}
+	}
+	return nil
+}
+
+func ValidateBranchName(exec Executor, branch string) error {
+	// Uses 'git check-ref-format --branch', which applies the rules for branch names, such as not starting with "-",
+	// on top of those for refs.
+	if err := checkArguments(branch); err != nil {
+		return err
+	}
+	cmdArr := []string{"git", "check-ref-format", "--branch", branch}
+	if _, err := runAndGetOutput(exec, cmdArr); err != nil {
+		return fmt.Errorf("%w: %q: %v", ErrInvalidRefName, branch, err)
+	}
+	return nil
+}
+
+func ValidateRefName(exec Executor, ref string) error {
+	// Validates a full ref name such as refs/heads/mainline or refs/tags/v1.0.
+	if err := checkArguments(ref); err != nil {
+		return err
+	}
+	cmdArr := []string{"git", "check-ref-format", ref}
+	if _, err := runAndGetOutput(exec, cmdArr); err != nil {
+		return fmt.Errorf("%w: %q: %v", ErrInvalidRefName, ref, err)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestOptionLikeArgumentsAreRejected(t *testing.T) {
+	setup()
+	hostile := "--output=/tmp/x"
+	functions := map[string]func(exec Executor) error{
+		"GetUpstreamForRef": func(exec Executor) error { _, err := GetUpstreamForRef(exec, hostile); return err },
+		"RefIsAheadBehind":  func(exec Executor) error { _, _, err := RefIsAheadBehind(exec, hostile); return err },
+		"GetLastCommitOnBranch": func(exec Executor) error {
+			_, err := GetLastCommitOnBranch(exec, "-n0")
+			return err
+		},
+		"GetMergeBase": func(exec Executor) error { _, err := GetMergeBase(exec, "HEAD~", hostile); return err },
+		"LastCommitOnBranch": func(exec Executor) error {
+			_, err := LastCommitOnBranch(exec, "-n0")
+			return err
+		},
+		"MergeBase": func(exec Executor) error { _, err := MergeBase(exec, "HEAD~", hostile); return err },
+		"CountCommitsWithGtOneParent": func(exec Executor) error {
+			_, err := CountCommitsWithGtOneParent(exec, "main", hostile)
+			return err
+		},
+		"GetGraphToHead": func(exec Executor) error {
+			_, err := GetGraphToHead(exec, "main", hostile, 10)
+			return err
+		},
+		"Checkout":            func(exec Executor) error { return Checkout(exec, "main", hostile) },
+		"Fetch":               func(exec Executor) error { return Fetch(exec, hostile) },
+		"Pull":                func(exec Executor) error { return Pull(exec, hostile, true) },
+		"ResetTarget":         func(exec Executor) error { return ResetTarget(exec, hostile) },
+		"DeleteBranch":        func(exec Executor) error { return DeleteBranch(exec, hostile) },
+		"MergeSourceToTarget": func(exec Executor) error { return MergeSourceToTarget(exec, hostile) },
+		"ValidateBranchName":  func(exec Executor) error { return ValidateBranchName(exec, hostile) },
+	}
+	for name, fn := range functions {
+		calls := [][]string{}
+		err := fn(createScriptedExecCommand(&calls, fakeResponse{}))
+		if !errors.Is(err, ErrUnsafeArgument) {
+			t.Errorf("%s: expected ErrUnsafeArgument, but received %v", name, err)
+		}
+		if len(calls) != 0 {
+			t.Errorf("%s: expected git not to run, but it ran %v", name, calls)
+		}
+	}
+}
+
+func TestEndOfOptions(t *testing.T) {
+	setup()
+	{
+		calls := [][]string{}
+		if _, err := GetLastCommitOnBranch(createScriptedExecCommand(&calls, fakeResponse{stdout: "abc"}), "main"); err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		expected := []string{"git", "log", "-n1", "--format=format:%H", "--end-of-options", "main", "--"}
+		if !reflect.DeepEqual(calls[0], expected) {
+			t.Fatalf("Expected %v, but received %v", expected, calls[0])
+		}
+	}
+	{
+		calls := [][]string{}
+		if _, err := GetMergeBase(createScriptedExecCommand(&calls, fakeResponse{stdout: "abc\n"}), "HEAD~", "main"); err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		expected := []string{"git", "merge-base", "--end-of-options", "main", "HEAD~"}
+		if !reflect.DeepEqual(calls[0], expected) {
+			t.Fatalf("Expected %v, but received %v", expected, calls[0])
+		}
+	}
+	{
+		calls := [][]string{}
+		if err := Pull(createScriptedExecCommand(&calls, fakeResponse{}), "feature", false); err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		expected := []string{"git", "pull", "--", ".", "feature"}
+		if !reflect.DeepEqual(calls[0], expected) {
+			t.Fatalf("Expected %v, but received %v", expected, calls[0])
+		}
+	}
+}
+
+func TestBranchIsAheadOfOriginQuotesBranch(t *testing.T) {
+	setup()
+	// Unquoted, "fix.1" would also match the line for "fix11".
+	output := "  fix11  68e43cb8b [origin/fix11: ahead 3] Other\n* fix.1  96be17e [origin/fix.1] Mine"
+	mockGit := createFakeExecCommand(output, 0)
+	ahead, _, err := BranchIsAheadOfOrigin(mockGit, "fix.1")
+	if err != nil || ahead {
+		t.Fatalf("Expected fix.1 not to be ahead, but received %v, %v", ahead, err)
+	}
+	// A branch name which is not a valid regular expression is matched literally too.
+	if _, _, err := BranchIsAheadOfOrigin(mockGit, "fix(1"); err == nil || errors.Is(err, ErrUnsafeArgument) {
+		t.Fatalf("Expected the branch not to be found, but received %v", err)
+	}
+}
+
+func TestValidateRefNames(t *testing.T) {
+	setup()
+	{
+		calls := [][]string{}
+		if err := ValidateBranchName(createScriptedExecCommand(&calls, fakeResponse{stdout: "feature/x\n"}), "feature/x"); err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		expected := []string{"git", "check-ref-format", "--branch", "feature/x"}
+		if !reflect.DeepEqual(calls[0], expected) {
+			t.Fatalf("Expected %v, but received %v", expected, calls[0])
+		}
+	}
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls,
+			fakeResponse{stderr: "fatal: 'a..b' is not a valid branch name\n", exitStatus: 128})
+		if err := ValidateBranchName(mockGit, "a..b"); !errors.Is(err, ErrInvalidRefName) {
+			t.Fatalf("Expected ErrInvalidRefName, but received %v", err)
+		}
+	}
+	{
+		calls := [][]string{}
+		if err := ValidateRefName(createScriptedExecCommand(&calls, fakeResponse{exitStatus: 1}), "refs/heads/a b"); !errors.Is(err, ErrInvalidRefName) {
+			t.Fatalf("Expected ErrInvalidRefName, but received %v", err)
+		}
+		expected := []string{"git", "check-ref-format", "refs/heads/a b"}
+		if !reflect.DeepEqual(calls[0], expected) {
+			t.Fatalf("Expected %v, but received %v", expected, calls[0])
+		}
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BlameLine is a line of a file together with the commit which last changed it.  Lines which have not been committed
+// are blamed on the zero ObjectID.
+type BlameLine struct {
+	Commit ObjectID
+	// OriginalLine is the line's number in OriginalPath as of Commit, and FinalLine its number in the blamed file.
+	// Both start at 1.
+	OriginalLine int
+	FinalLine    int
+	// OriginalPath is the path of the file in Commit, which differs from the blamed path when the file was renamed or
+	// the line was moved or copied from another file.
+	OriginalPath string
+	Author       string
+	AuthorEmail  string
+	// AuthorTime is in the author's time zone.
+	AuthorTime time.Time
+	Summary    string
+	// PreviousCommit and PreviousPath locate the file in the parent of Commit, for continuing to blame the line
+	// further back.  They are empty when Commit added the file.
+	PreviousCommit ObjectID
+	PreviousPath   string
+	// Boundary is true when Commit is the oldest commit considered, such as a root commit or the excluded end of a
+	// range, so that the line may be older.
+	Boundary bool
+	Text     string
+}
+
+// BlameOptions controls Blame.
+type BlameOptions struct {
+	// Ranges restricts blame to ranges of lines given as for -L, such as "10,20", "10,+5", "/regex/,+3" or
+	// ":funcname".
+	Ranges []string
+	// IgnoreWhitespace ignores changes which only add or remove whitespace when finding a line's commit.
+	IgnoreWhitespace bool
+	// DetectMoves follows lines moved within the file.  DetectCopies, from 1 to 3, also follows lines moved or copied
+	// from other files: changed in the same commit, then in the commit creating the file, then in any commit.
+	DetectMoves  bool
+	DetectCopies int
+	// IgnoreRevsFile names a file of revisions, such as formatting changes, to look past.
+	IgnoreRevsFile string
+}
+
+// blameCommit holds the details --porcelain reports only for the first line blamed on a commit.
+type blameCommit struct {
+	author         string
+	authorEmail    string
+	authorTime     int64
+	authorZone     string
+	summary        string
+	previousCommit ObjectID
+	previousPath   string
+	boundary       bool
+	filename       string
+}
+
+func Blame(exec Executor, path string, revision string, opts BlameOptions) ([]BlameLine, error) {
+	// Blames each line of path as of revision, or of the file in the working tree when revision is empty.
+	// --porcelain reports each line as a header, details of the commit the first time it appears and the line's text:
+	// <commit> SP <original line> SP <final line> [SP <lines in group>] LF
+	// author <name> LF author-mail <<email>> LF author-time <seconds> LF author-tz <+hhmm> LF ...
+	// summary <subject> LF [boundary LF] [previous <commit> SP <path> LF] filename <path> LF
+	// TAB <text> LF
+	// The filename is repeated for later lines of commits which touch several paths.  Paths may be quoted.
+	if err := checkArguments(revision); err != nil {
+		return nil, err
+	}
+	cmdArr := []string{"git", "blame", "--porcelain"}
+	for _, lineRange := range opts.Ranges {
+		cmdArr = append(cmdArr, "-L", lineRange)
+	}
+	if opts.IgnoreWhitespace {
+		cmdArr = append(cmdArr, "-w")
+	}
+	if opts.DetectMoves {
+		cmdArr = append(cmdArr, "-M")
+	}
+	for i := 0; i < opts.DetectCopies && i < 3; i++ {
+		cmdArr = append(cmdArr, "-C")
+	}
+	if len(opts.IgnoreRevsFile) != 0 {
+		cmdArr = append(cmdArr, "--ignore-revs-file", opts.IgnoreRevsFile)
+	}
+	// blame does not accept --end-of-options, but checkArguments has ruled out revisions which look like options.
+	if len(revision) != 0 {
+		cmdArr = append(cmdArr, revision)
+	}
+	cmdArr = append(cmdArr, "--", path)
+	out, err := runAndGetOutput(exec, cmdArr)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := []BlameLine{}
+	commits := map[ObjectID]*blameCommit{}
+	var current *BlameLine
+	var commit *blameCommit
+	for _, line := range strings.Split(strings.TrimSuffix(string(out), "\n"), "\n") {
+		if len(line) == 0 {
+			continue
+		}
+		if line[0] == '\t' {
+			if current == nil {
+				return nil, errors.New("Unrecognized blame output: " + line)
+			}
+			current.Text = line[1:]
+			if len(current.OriginalPath) == 0 {
+				current.OriginalPath = commit.filename
+			}
+			current.Author, current.AuthorEmail, current.Summary = commit.author, commit.authorEmail, commit.summary
+			current.AuthorTime = parseGitTime(commit.authorTime, commit.authorZone)
+			current.PreviousCommit, current.PreviousPath = commit.previousCommit, commit.previousPath
+			current.Boundary = commit.boundary
+			lines = append(lines, *current)
+			current = nil
+			continue
+		}
+		if current == nil {
+			fields := strings.Split(line, " ")
+			if len(fields) < 3 || len(fields) > 4 {
+				return nil, errors.New("Unrecognized blame output: " + line)
+			}
+			id, err := ParseObjectID(fields[0])
+			if err != nil {
+				return nil, err
+			}
+			current = &BlameLine{Commit: id}
+			if current.OriginalLine, err = strconv.Atoi(fields[1]); err != nil {
+				return nil, err
+			}
+			if current.FinalLine, err = strconv.Atoi(fields[2]); err != nil {
+				return nil, err
+			}
+			if commit = commits[id]; commit == nil {
+				commit = &blameCommit{}
+				commits[id] = commit
+			}
+			continue
+		}
+		key, value := line, ""
+		if space := strings.IndexByte(line, ' '); space >= 0 {
+			key, value = line[:space], line[space+1:]
+		}
+		switch key {
+		case "author":
+			commit.author = value
+		case "author-mail":
+			commit.authorEmail = strings.TrimSuffix(strings.TrimPrefix(value, "<"), ">")
+		case "author-time":
+			if commit.authorTime, err = strconv.ParseInt(value, 10, 64); err != nil {
+				return nil, err
+			}
+		case "author-tz":
+			commit.authorZone = value
+		case "summary":
+			commit.summary = value
+		case "boundary":
+			commit.boundary = true
+		case "previous":
+			space := strings.IndexByte(value, ' ')
+			if space < 0 {
+				return nil, errors.New("Unrecognized blame output: " + line)
+			}
+			if commit.previousCommit, err = ParseObjectID(value[:space]); err != nil {
+				return nil, err
+			}
+			commit.previousPath = unquotePath(value[space+1:])
+		case "filename":
+			commit.filename = unquotePath(value)
+			current.OriginalPath = commit.filename
+		}
+	}
+	if current != nil {
+		return nil, errors.New("Unrecognized blame output: missing text for line " + strconv.Itoa(current.FinalLine))
+	}
+	return lines, nil
+}
+
+// parseGitTime returns the time of seconds since the epoch in zone, an offset such as "+0530" or "-0800".
+func parseGitTime(seconds int64, zone string) time.Time {
+	t := time.Unix(seconds, 0)
+	if len(zone) != 5 || (zone[0] != '+' && zone[0] != '-') {
+		return t
+	}
+	hours, hoursErr := strconv.Atoi(zone[1:3])
+	minutes, minutesErr := strconv.Atoi(zone[3:])
+	if hoursErr != nil || minutesErr != nil {
+		return t
+	}
+	offset := hours*60*60 + minutes*60
+	if zone[0] == '-' {
+		offset = -offset
+	}
+	return t.In(time.FixedZone(zone, offset))
+}
+
+// unquotePath returns a path git reported, undoing the C style quoting git applies to paths containing special or,
+// with core.quotePath, non-ASCII characters.
+func unquotePath(path string) string {
+	if !strings.HasPrefix(path, `"`) {
+		return path
+	}
+	unquoted, err := strconv.Unquote(path)
+	if err != nil {
+		return path
+	}
+	return unquoted
+}
@@ -0,0 +1,80 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBlame(t *testing.T) {
+	setup()
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{stdout: "" +
+			"cba6c2b4cb9e4e7b47beb07068834382fba2e3dc 1 1 1\n" +
+			"author A U Thor\nauthor-mail <a@example.com>\nauthor-time 1700000000\nauthor-tz +0530\n" +
+			"committer C\ncommitter-mail <c@example.com>\ncommitter-time 1700000000\ncommitter-tz +0000\n" +
+			"summary First\nboundary\nfilename old.go\n\tpackage main\n" +
+			"8328663c14851a0431dfc78b830212a01f9a804f 3 2 2\n" +
+			"author B\nauthor-mail <b@example.com>\nauthor-time 1700003600\nauthor-tz -0800\n" +
+			"summary Second\nprevious cba6c2b4cb9e4e7b47beb07068834382fba2e3dc \"\\303\\251 x.go\"\n" +
+			"filename \"\\303\\251 x.go\"\n\t\n" +
+			"8328663c14851a0431dfc78b830212a01f9a804f 4 3\n\tfunc main() {}\n" +
+			"cba6c2b4cb9e4e7b47beb07068834382fba2e3dc 2 4 1\n\t// end\n"})
+		lines, err := Blame(mockGit, "é x.go", "v1.0", BlameOptions{Ranges: []string{"1,4", ":main"},
+			IgnoreWhitespace: true, DetectMoves: true, DetectCopies: 2, IgnoreRevsFile: ".git-blame-ignore-revs"})
+		if err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		first := time.Unix(1700000000, 0).In(time.FixedZone("+0530", 5*60*60+30*60))
+		second := time.Unix(1700003600, 0).In(time.FixedZone("-0800", -8*60*60))
+		expected := []BlameLine{
+			{Commit: "cba6c2b4cb9e4e7b47beb07068834382fba2e3dc", OriginalLine: 1, FinalLine: 1, OriginalPath: "old.go",
+				Author: "A U Thor", AuthorEmail: "a@example.com", AuthorTime: first, Summary: "First", Boundary: true,
+				Text: "package main"},
+			{Commit: "8328663c14851a0431dfc78b830212a01f9a804f", OriginalLine: 3, FinalLine: 2, OriginalPath: "é x.go",
+				Author: "B", AuthorEmail: "b@example.com", AuthorTime: second, Summary: "Second",
+				PreviousCommit: "cba6c2b4cb9e4e7b47beb07068834382fba2e3dc", PreviousPath: "é x.go"},
+			{Commit: "8328663c14851a0431dfc78b830212a01f9a804f", OriginalLine: 4, FinalLine: 3, OriginalPath: "é x.go",
+				Author: "B", AuthorEmail: "b@example.com", AuthorTime: second, Summary: "Second",
+				PreviousCommit: "cba6c2b4cb9e4e7b47beb07068834382fba2e3dc", PreviousPath: "é x.go",
+				Text: "func main() {}"},
+			{Commit: "cba6c2b4cb9e4e7b47beb07068834382fba2e3dc", OriginalLine: 2, FinalLine: 4, OriginalPath: "old.go",
+				Author: "A U Thor", AuthorEmail: "a@example.com", AuthorTime: first, Summary: "First", Boundary: true,
+				Text: "// end"},
+		}
+		if !reflect.DeepEqual(lines, expected) {
+			t.Fatalf("Expected %+v, but received %+v", expected, lines)
+		}
+		expectedCmd := []string{"git", "blame", "--porcelain", "-L", "1,4", "-L", ":main", "-w", "-M", "-C", "-C",
+			"--ignore-revs-file", ".git-blame-ignore-revs", "v1.0", "--", "é x.go"}
+		if !reflect.DeepEqual(calls[0], expectedCmd) {
+			t.Fatalf("Expected %v, but received %v", expectedCmd, calls[0])
+		}
+	}
+	{ // Without a revision the working tree is blamed.
+		calls := [][]string{}
+		if _, err := Blame(createScriptedExecCommand(&calls, fakeResponse{}), "a.go", "", BlameOptions{}); err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		expectedCmd := []string{"git", "blame", "--porcelain", "--", "a.go"}
+		if !reflect.DeepEqual(calls[0], expectedCmd) {
+			t.Fatalf("Expected %v, but received %v", expectedCmd, calls[0])
+		}
+	}
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls,
+			fakeResponse{stdout: "cba6c2b4cb9e4e7b47beb07068834382fba2e3dc 1 1 1\nauthor A\n"})
+		if _, err := Blame(mockGit, "a.go", "", BlameOptions{}); err == nil {
+			t.Fatalf("Expected non-nil error")
+		}
+		if _, err := Blame(mockGit, "a.go", "--since=1", BlameOptions{}); !errors.Is(err, ErrUnsafeArgument) {
+			t.Fatalf("Expected ErrUnsafeArgument, but received %v", err)
+		}
+	}
+}
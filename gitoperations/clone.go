@@ -0,0 +1,97 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"errors"
+	"strconv"
+)
+
+// CloneOptions controls Clone.  The zero value makes a full clone with a working tree.
+type CloneOptions struct {
+	// Branch checks out the given branch, or the commit a tag points at, instead of the remote's HEAD.
+	Branch string
+	// Depth makes a shallow clone truncated to the given number of commits when greater than zero.
+	Depth int
+	// Filter makes a partial clone, for example "blob:none" to fetch blobs on demand or "tree:0" for trees too.
+	Filter string
+	// Sparse initializes a sparse checkout containing only the files at the top level.
+	Sparse bool
+	// Mirror makes a bare clone which maps every remote ref, and Bare makes a bare clone of the branches.
+	Mirror bool
+	Bare   bool
+	// Reference borrows objects from the local repository at the given path.
+	Reference    string
+	SingleBranch bool
+	// RecurseSubmodules initializes and clones submodules after the clone is created.
+	RecurseSubmodules bool
+	// Progress, when non-nil, receives progress as the clone proceeds.
+	Progress ProgressFunc
+}
+
+// InitOptions controls Init.
+type InitOptions struct {
+	// InitialBranch names the branch HEAD points at.  When empty git uses init.defaultBranch.
+	InitialBranch string
+	Bare          bool
+}
+
+func Clone(exec Executor, url string, dir string, opts CloneOptions) error {
+	// Clones url into dir.  The directory is required, rather than derived from the URL by git, so that callers know
+	// where the repository was created.
+	if len(dir) == 0 {
+		return errors.New("A directory is required to clone into.")
+	}
+	cmdArr := []string{"git", "clone"}
+	if len(opts.Branch) != 0 {
+		cmdArr = append(cmdArr, "--branch="+opts.Branch)
+	}
+	if opts.Depth > 0 {
+		cmdArr = append(cmdArr, "--depth="+strconv.Itoa(opts.Depth))
+	}
+	if len(opts.Filter) != 0 {
+		cmdArr = append(cmdArr, "--filter="+opts.Filter)
+	}
+	if opts.Sparse {
+		cmdArr = append(cmdArr, "--sparse")
+	}
+	if opts.Mirror {
+		cmdArr = append(cmdArr, "--mirror")
+	}
+	if opts.Bare {
+		cmdArr = append(cmdArr, "--bare")
+	}
+	if len(opts.Reference) != 0 {
+		cmdArr = append(cmdArr, "--reference="+opts.Reference)
+	}
+	if opts.SingleBranch {
+		cmdArr = append(cmdArr, "--single-branch")
+	}
+	if opts.RecurseSubmodules {
+		cmdArr = append(cmdArr, "--recurse-submodules")
+	}
+	if opts.Progress != nil {
+		cmdArr = append(cmdArr, "--progress")
+	}
+	cmdArr = append(cmdArr, "--", url, dir)
+	_, _, err := runAndReportProgress(exec, cmdArr, opts.Progress)
+	return err
+}
+
+func Init(exec Executor, dir string, opts InitOptions) error {
+	// Creates an empty repository in dir, or reinitializes an existing one.
+	if len(dir) == 0 {
+		return errors.New("A directory is required to initialize.")
+	}
+	cmdArr := []string{"git", "init", "--quiet"}
+	if len(opts.InitialBranch) != 0 {
+		cmdArr = append(cmdArr, "--initial-branch="+opts.InitialBranch)
+	}
+	if opts.Bare {
+		cmdArr = append(cmdArr, "--bare")
+	}
+	cmdArr = append(cmdArr, "--", dir)
+	_, err := runAndGetOutput(exec, cmdArr)
+	return err
+}
@@ -0,0 +1,86 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	setup()
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{})
+		opts := CloneOptions{Branch: "release", Depth: 1, Filter: "blob:none", Sparse: true,
+			Reference: "/cache/repo.git", SingleBranch: true, RecurseSubmodules: true}
+		if err := Clone(mockGit, "https://example.com/repo.git", "work", opts); err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		expected := []string{"git", "clone", "--branch=release", "--depth=1", "--filter=blob:none", "--sparse",
+			"--reference=/cache/repo.git", "--single-branch", "--recurse-submodules", "--",
+			"https://example.com/repo.git", "work"}
+		if !reflect.DeepEqual(calls[0], expected) {
+			t.Fatalf("Expected %v, but received %v", expected, calls[0])
+		}
+	}
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{})
+		if err := Clone(mockGit, "https://example.com/repo.git", "repo.git", CloneOptions{Mirror: true}); err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		expected := []string{"git", "clone", "--mirror", "--", "https://example.com/repo.git", "repo.git"}
+		if !reflect.DeepEqual(calls[0], expected) {
+			t.Fatalf("Expected %v, but received %v", expected, calls[0])
+		}
+	}
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls,
+			fakeResponse{stderr: "fatal: destination path 'work' already exists\n", exitStatus: 128})
+		if err := Clone(mockGit, "https://example.com/repo.git", "work", CloneOptions{}); exitCodeOf(err) != 128 {
+			t.Fatalf("Expected exit status 128, but received %v", err)
+		}
+	}
+	{
+		calls := [][]string{}
+		if err := Clone(createScriptedExecCommand(&calls, fakeResponse{}), "x", "", CloneOptions{}); err == nil {
+			t.Fatalf("Expected non-nil error")
+		}
+	}
+}
+
+func TestInit(t *testing.T) {
+	setup()
+	calls := [][]string{}
+	mockGit := createScriptedExecCommand(&calls, fakeResponse{})
+	if err := Init(mockGit, "repo.git", InitOptions{InitialBranch: "mainline", Bare: true}); err != nil {
+		t.Fatalf("Expected nil error, but received %v", err)
+	}
+	expected := []string{"git", "init", "--quiet", "--initial-branch=mainline", "--bare", "--", "repo.git"}
+	if !reflect.DeepEqual(calls[0], expected) {
+		t.Fatalf("Expected %v, but received %v", expected, calls[0])
+	}
+	if err := Init(mockGit, "", InitOptions{}); err == nil {
+		t.Fatalf("Expected non-nil error")
+	}
+}
+
+func TestControllerForDir(t *testing.T) {
+	controller := &realController{opts: ControllerOptions{Dir: "/work"}}
+	if cmd := controller.executor()("git", "status"); cmd.Dir != "/work" {
+		t.Fatalf("Expected the command to run in /work, but it runs in '%s'", cmd.Dir)
+	}
+	if cmd := new(realController).executor()("git", "status"); cmd.Dir != "" {
+		t.Fatalf("Expected the command to run in the working directory, but it runs in '%s'", cmd.Dir)
+	}
+	if dir := controller.forDir("clone").(*realController).opts.Dir; dir != filepath.Join("/work", "clone") {
+		t.Fatalf("Unexpected directory '%s'", dir)
+	}
+	if dir := controller.forDir("/elsewhere").(*realController).opts.Dir; dir != "/elsewhere" {
+		t.Fatalf("Unexpected directory '%s'", dir)
+	}
+}
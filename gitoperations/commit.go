@@ -0,0 +1,227 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// CommitOptions controls CreateCommit.
+type CommitOptions struct {
+	// Message is the commit message.  When amending, an empty Message keeps the message of the amended commit.
+	Message string
+	// All stages changes to tracked files before committing, like 'git commit --all'.
+	All        bool
+	AllowEmpty bool
+	// Amend replaces the current commit rather than adding a new one.
+	Amend bool
+	// Author, when set, overrides the author, in the form "Name <email>".
+	Author  string
+	Signing SigningOptions
+}
+
+// MergeOptions controls Merge.  By default git fast-forwards when it can and otherwise creates a merge commit.
+type MergeOptions struct {
+	// Message, when set, replaces the default merge commit message.
+	Message string
+	// NoFastForward always creates a merge commit, and FastForwardOnly fails unless the merge is a fast-forward.
+	NoFastForward   bool
+	FastForwardOnly bool
+	// Squash stages the merged changes without committing or recording a merge.
+	Squash bool
+	// NoCommit stops before creating the merge commit, so that the result can be inspected.
+	NoCommit bool
+	Signing  SigningOptions
+}
+
+// TagOptions controls CreateTag.
+type TagOptions struct {
+	// Message makes the tag an annotated tag.  Tags are also annotated when they are signed, which may be because of
+	// tag.gpgSign, and then require a Message.
+	Message string
+	// Force replaces an existing tag of the same name.
+	Force   bool
+	Signing SigningOptions
+}
+
+func CreateCommit(exec Executor, opts CommitOptions) (ObjectID, error) {
+	// Commits what is staged, returning the id of the new commit.  The message is passed on stdin so that git never
+	// opens an editor.
+	cmdArr := append([]string{"git"}, opts.Signing.configArgs()...)
+	cmdArr = append(cmdArr, "commit", "--quiet")
+	var input []byte
+	if opts.Amend && len(opts.Message) == 0 {
+		cmdArr = append(cmdArr, "--no-edit")
+	} else {
+		cmdArr = append(cmdArr, "--file=-")
+		input = []byte(opts.Message)
+	}
+	if opts.All {
+		cmdArr = append(cmdArr, "--all")
+	}
+	if opts.AllowEmpty {
+		cmdArr = append(cmdArr, "--allow-empty")
+	}
+	if opts.Amend {
+		cmdArr = append(cmdArr, "--amend")
+	}
+	if len(opts.Author) != 0 {
+		cmdArr = append(cmdArr, "--author="+opts.Author)
+	}
+	cmdArr = append(cmdArr, opts.Signing.commitArgs()...)
+	if _, err := runWithInputAndGetOutput(exec, cmdArr, input); err != nil {
+		return "", signingError(err)
+	}
+	return ResolveCommit(exec, "HEAD")
+}
+
+func CommitTree(exec Executor, tree string, parents []string, message string, signing SigningOptions) (ObjectID,
+	error) {
+	// Creates a commit of tree without touching HEAD, the index or the working tree, returning its id.  Unlike 'git
+	// commit', 'git commit-tree' ignores commit.gpgSign, so it is read here for SignDefault.
+	if err := checkArguments(append([]string{tree}, parents...)...); err != nil {
+		return "", err
+	}
+	if signing.Mode == SignDefault {
+		sign, err := ConfigGetBool(exec, ConfigScopeAll, "commit.gpgSign")
+		if err != nil && !errors.Is(err, ErrConfigKeyNotFound) {
+			return "", err
+		}
+		if sign {
+			signing.Mode = SignAlways
+		}
+	}
+	cmdArr := append([]string{"git"}, signing.configArgs()...)
+	cmdArr = append(cmdArr, "commit-tree")
+	for _, parent := range parents {
+		cmdArr = append(cmdArr, "-p", parent)
+	}
+	cmdArr = append(cmdArr, signing.commitArgs()...)
+	cmdArr = append(cmdArr, "-F", "-", "--end-of-options", tree)
+	out, err := runWithInputAndGetOutput(exec, cmdArr, []byte(message))
+	if err != nil {
+		return "", signingError(err)
+	}
+	return ParseObjectID(string(out))
+}
+
+func Merge(exec Executor, revisions []string, opts MergeOptions) ([]Conflict, error) {
+	// Merges revisions into the current branch.  When the merge stops on conflicts they are returned together with an
+	// error matching ErrConflict.
+	if err := checkArguments(revisions...); err != nil {
+		return nil, err
+	}
+	cmdArr := append([]string{"git"}, opts.Signing.configArgs()...)
+	cmdArr = append(cmdArr, "merge", "--quiet", "--no-edit")
+	if opts.NoFastForward {
+		cmdArr = append(cmdArr, "--no-ff")
+	}
+	if opts.FastForwardOnly {
+		cmdArr = append(cmdArr, "--ff-only")
+	}
+	if opts.Squash {
+		cmdArr = append(cmdArr, "--squash")
+	}
+	if opts.NoCommit {
+		cmdArr = append(cmdArr, "--no-commit")
+	}
+	if len(opts.Message) != 0 {
+		cmdArr = append(cmdArr, "-m", opts.Message)
+	}
+	cmdArr = append(cmdArr, opts.Signing.commitArgs()...)
+	cmdArr = append(cmdArr, "--end-of-options")
+	cmdArr = append(cmdArr, revisions...)
+	_, err := runAndGetOutput(exec, cmdArr)
+	if err == nil {
+		return nil, nil
+	}
+	if signErr := signingError(err); signErr != err {
+		return nil, signErr
+	}
+	conflicts, listErr := ListConflicts(exec)
+	if listErr != nil || len(conflicts) == 0 {
+		return nil, err
+	}
+	return conflicts, fmt.Errorf("%w: %v", ErrConflict, err)
+}
+
+var reForErrorLine = regexp.MustCompile(`(?m)^error: `)
+
+func CreateTag(exec Executor, name string, target string, opts TagOptions) error {
+	// Tags target, or HEAD when target is empty.  Some versions of git report a failure to sign with SSH but create
+	// the tag unsigned all the same; the tag is then restored to what it was and the failure reported.
+	if err := checkArguments(name, target); err != nil {
+		return err
+	}
+	annotated := len(opts.Message) != 0 || opts.Signing.Mode == SignAlways
+	if !annotated && opts.Signing.Mode == SignDefault {
+		// With tag.gpgSign git would sign, and so annotate, the tag, opening an editor for its message.
+		sign, err := ConfigGetBool(exec, ConfigScopeAll, "tag.gpgSign")
+		if err != nil && !errors.Is(err, ErrConfigKeyNotFound) {
+			return err
+		}
+		if sign {
+			return errors.New("A message is required for tag " + name + ", which tag.gpgSign signs.")
+		}
+	}
+	ref := "refs/tags/" + name
+	var previous ObjectID
+	if opts.Force && opts.Signing.Mode != SignNever {
+		var err error
+		if previous, err = resolveRef(exec, ref); err != nil {
+			return err
+		}
+	}
+	cmdArr := append([]string{"git"}, opts.Signing.configArgs()...)
+	cmdArr = append(cmdArr, "tag")
+	if opts.Force {
+		cmdArr = append(cmdArr, "--force")
+	}
+	var input []byte
+	if annotated {
+		cmdArr = append(cmdArr, "--annotate", "--file=-")
+		input = []byte(opts.Message)
+	}
+	switch opts.Signing.Mode {
+	case SignAlways:
+		cmdArr = append(cmdArr, "--sign")
+	case SignNever:
+		cmdArr = append(cmdArr, "--no-sign")
+	}
+	cmdArr = append(cmdArr, "--end-of-options", name)
+	if len(target) != 0 {
+		cmdArr = append(cmdArr, target)
+	}
+	_, stderr, err := runAndGetSeparateOutputs(exec, cmdArr, input)
+	if err != nil {
+		return signingError(err)
+	}
+	if opts.Signing.Mode == SignNever || !reForErrorLine.Match(stderr) {
+		return nil
+	}
+	restoreArr := []string{"git", "update-ref", "-d", ref}
+	if len(previous) != 0 {
+		restoreArr = []string{"git", "update-ref", ref, previous.String()}
+	}
+	if _, err := runAndGetOutput(exec, restoreArr); err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: %s", ErrSigningFailed, strings.TrimSpace(string(stderr)))
+}
+
+// resolveRef returns the object ref points to, or the empty ObjectID when it does not exist.
+func resolveRef(exec Executor, ref string) (ObjectID, error) {
+	cmdArr := []string{"git", "rev-parse", "--quiet", "--verify", "--end-of-options", ref}
+	out, err := runAndGetOutput(exec, cmdArr)
+	if exitCodeOf(err) == 1 {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return ParseObjectID(string(out))
+}
@@ -0,0 +1,194 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCreateCommit(t *testing.T) {
+	setup()
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{}, fakeResponse{stdout: testSHA1 + "\n"})
+		id, err := CreateCommit(mockGit, CommitOptions{Message: "Fix it", All: true, AllowEmpty: true,
+			Author: "A U Thor <a@example.com>", Signing: SigningOptions{Mode: SignAlways, KeyID: "ABCD"}})
+		if err != nil || id != testSHA1 {
+			t.Fatalf("Expected %s, but received %q, %v", testSHA1, id, err)
+		}
+		expectedCmd := []string{"git", "-c", "user.signingKey=ABCD", "commit", "--quiet", "--file=-", "--all",
+			"--allow-empty", "--author=A U Thor <a@example.com>", "--gpg-sign"}
+		if !reflect.DeepEqual(calls[0], expectedCmd) {
+			t.Fatalf("Expected %v, but received %v", expectedCmd, calls[0])
+		}
+	}
+	{ // Amending without a message keeps the old one.
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{}, fakeResponse{stdout: testSHA1 + "\n"})
+		if _, err := CreateCommit(mockGit, CommitOptions{Amend: true}); err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		expectedCmd := []string{"git", "commit", "--quiet", "--no-edit", "--amend"}
+		if !reflect.DeepEqual(calls[0], expectedCmd) {
+			t.Fatalf("Expected %v, but received %v", expectedCmd, calls[0])
+		}
+	}
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{exitStatus: 128,
+			stderr: "error: gpg failed to sign the data\nfatal: failed to write commit object\n"})
+		if _, err := CreateCommit(mockGit, CommitOptions{Message: "x"}); !errors.Is(err, ErrSigningFailed) {
+			t.Fatalf("Expected ErrSigningFailed, but received %v", err)
+		}
+	}
+}
+
+func TestCommitTree(t *testing.T) {
+	setup()
+	{ // commit.gpgSign is applied by the library, since commit-tree ignores it.
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{stdout: "true\x00"},
+			fakeResponse{stdout: testSHA1 + "\n"})
+		id, err := CommitTree(mockGit, "HEAD^{tree}", []string{"HEAD", "topic"}, "Squashed", SigningOptions{})
+		if err != nil || id != testSHA1 {
+			t.Fatalf("Expected %s, but received %q, %v", testSHA1, id, err)
+		}
+		expectedCmds := [][]string{
+			{"git", "config", "--type=bool", "--null", "--get", "--", "commit.gpgSign"},
+			{"git", "commit-tree", "-p", "HEAD", "-p", "topic", "--gpg-sign", "-F", "-", "--end-of-options",
+				"HEAD^{tree}"},
+		}
+		if !reflect.DeepEqual(calls, expectedCmds) {
+			t.Fatalf("Expected %v, but received %v", expectedCmds, calls)
+		}
+	}
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{exitStatus: 1},
+			fakeResponse{stdout: testSHA1 + "\n"})
+		if _, err := CommitTree(mockGit, "HEAD^{tree}", nil, "Root", SigningOptions{}); err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		expectedCmd := []string{"git", "commit-tree", "-F", "-", "--end-of-options", "HEAD^{tree}"}
+		if !reflect.DeepEqual(calls[1], expectedCmd) {
+			t.Fatalf("Expected %v, but received %v", expectedCmd, calls[1])
+		}
+	}
+	{
+		calls := [][]string{}
+		_, err := CommitTree(createScriptedExecCommand(&calls, fakeResponse{}), "HEAD^{tree}", []string{"-p"}, "x",
+			SigningOptions{Mode: SignNever})
+		if !errors.Is(err, ErrUnsafeArgument) {
+			t.Fatalf("Expected ErrUnsafeArgument, but received %v", err)
+		}
+	}
+}
+
+func TestMerge(t *testing.T) {
+	setup()
+	{
+		calls := [][]string{}
+		conflicts, err := Merge(createScriptedExecCommand(&calls, fakeResponse{}), []string{"topic"},
+			MergeOptions{Message: "Merge topic", NoFastForward: true, Signing: SigningOptions{Mode: SignNever}})
+		if err != nil || conflicts != nil {
+			t.Fatalf("Expected no conflicts and nil error, but received %v, %v", conflicts, err)
+		}
+		expectedCmd := []string{"git", "merge", "--quiet", "--no-edit", "--no-ff", "-m", "Merge topic",
+			"--no-gpg-sign", "--end-of-options", "topic"}
+		if !reflect.DeepEqual(calls[0], expectedCmd) {
+			t.Fatalf("Expected %v, but received %v", expectedCmd, calls[0])
+		}
+	}
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls,
+			fakeResponse{stdout: "CONFLICT (content): Merge conflict in a.txt\n", exitStatus: 1},
+			fakeResponse{stdout: "100644 " + testSHA1 + " 1\ta.txt\x00100644 " + testSHA1 + " 2\ta.txt\x00"})
+		conflicts, err := Merge(mockGit, []string{"topic"}, MergeOptions{Squash: true})
+		if !errors.Is(err, ErrConflict) || len(conflicts) != 1 || conflicts[0].Path != "a.txt" {
+			t.Fatalf("Expected a conflict in a.txt and ErrConflict, but received %+v, %v", conflicts, err)
+		}
+	}
+}
+
+func TestCreateTag(t *testing.T) {
+	setup()
+	{
+		calls := [][]string{}
+		err := CreateTag(createScriptedExecCommand(&calls, fakeResponse{}), "v1.0", "",
+			TagOptions{Message: "Release", Signing: SigningOptions{Mode: SignAlways, Format: SignatureFormatX509}})
+		if err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		expectedCmd := []string{"git", "-c", "gpg.format=x509", "tag", "--annotate", "--file=-", "--sign",
+			"--end-of-options", "v1.0"}
+		if !reflect.DeepEqual(calls[0], expectedCmd) {
+			t.Fatalf("Expected %v, but received %v", expectedCmd, calls[0])
+		}
+	}
+	{
+		calls := [][]string{}
+		err := CreateTag(createScriptedExecCommand(&calls, fakeResponse{}), "light", "HEAD~",
+			TagOptions{Force: true, Signing: SigningOptions{Mode: SignNever}})
+		if err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		expectedCmd := []string{"git", "tag", "--force", "--no-sign", "--end-of-options", "light", "HEAD~"}
+		if !reflect.DeepEqual(calls, [][]string{expectedCmd}) {
+			t.Fatalf("Expected %v, but received %v", expectedCmd, calls)
+		}
+	}
+	{ // Git may report the failure to sign but create the tag regardless, which is then restored.
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls,
+			fakeResponse{stdout: testSHA1 + "\n"},
+			fakeResponse{stderr: "error: Load key \"/k\": incorrect passphrase supplied to decrypt private key?\n"},
+			fakeResponse{})
+		err := CreateTag(mockGit, "v1.0", "", TagOptions{Message: "Release", Force: true,
+			Signing: SigningOptions{Mode: SignAlways}})
+		if !errors.Is(err, ErrSigningFailed) {
+			t.Fatalf("Expected ErrSigningFailed, but received %v", err)
+		}
+		expectedCmd := []string{"git", "update-ref", "refs/tags/v1.0", testSHA1}
+		if len(calls) != 3 || !reflect.DeepEqual(calls[2], expectedCmd) {
+			t.Fatalf("Expected %v last, but received %v", expectedCmd, calls)
+		}
+	}
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{stderr: "error: Load key \"/k\": invalid format\n"},
+			fakeResponse{})
+		if err := CreateTag(mockGit, "v1.0", "", TagOptions{Message: "Release"}); !errors.Is(err, ErrSigningFailed) {
+			t.Fatalf("Expected ErrSigningFailed, but received %v", err)
+		}
+		expectedCmd := []string{"git", "update-ref", "-d", "refs/tags/v1.0"}
+		if !reflect.DeepEqual(calls[1], expectedCmd) {
+			t.Fatalf("Expected %v, but received %v", expectedCmd, calls[1])
+		}
+	}
+	{ // Without a message tag.gpgSign is checked, since git would sign the tag and open an editor for its message.
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{stdout: "true\x00"})
+		if err := CreateTag(mockGit, "light", "", TagOptions{}); err == nil {
+			t.Fatalf("Expected non-nil error")
+		}
+		expectedCmds := [][]string{{"git", "config", "--type=bool", "--null", "--get", "--", "tag.gpgSign"}}
+		if !reflect.DeepEqual(calls, expectedCmds) {
+			t.Fatalf("Expected %v, but received %v", expectedCmds, calls)
+		}
+	}
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{exitStatus: 1}, fakeResponse{})
+		if err := CreateTag(mockGit, "light", "", TagOptions{}); err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		expectedCmd := []string{"git", "tag", "--end-of-options", "light"}
+		if len(calls) != 2 || !reflect.DeepEqual(calls[1], expectedCmd) {
+			t.Fatalf("Expected %v last, but received %v", expectedCmd, calls)
+		}
+	}
+}
@@ -0,0 +1,307 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrConfigKeyNotFound is returned, wrapped, when reading a config key which is not set.
+var ErrConfigKeyNotFound = errors.New("Config key not found")
+
+// ErrInvalidConfigKey is returned, wrapped, for keys git rejects, such as those without a section, and for invalid
+// key patterns.
+var ErrInvalidConfigKey = errors.New("Invalid config key")
+
+// ErrConfigMultipleValues is returned, wrapped, when setting or unsetting a single value of a key which has several.
+var ErrConfigMultipleValues = errors.New("Config key has multiple values")
+
+// ConfigScope selects the configuration files a config function reads or writes.  ConfigScopeAll reads the merged
+// configuration from every file and writes, as git does, to the repository's local configuration.  ConfigFileScope
+// selects a single file.
+type ConfigScope string
+
+const (
+	ConfigScopeAll      ConfigScope = ""
+	ConfigScopeLocal    ConfigScope = "local"
+	ConfigScopeGlobal   ConfigScope = "global"
+	ConfigScopeSystem   ConfigScope = "system"
+	ConfigScopeWorktree ConfigScope = "worktree"
+	// ConfigScopeCommand is reported for values set with 'git -c' and for files selected with ConfigFileScope.  It
+	// cannot be used to select files.
+	ConfigScopeCommand ConfigScope = "command"
+)
+
+const configFileScopePrefix = "file:"
+
+// ConfigFileScope returns the scope of the configuration file at path.
+func ConfigFileScope(path string) ConfigScope {
+	return ConfigScope(configFileScopePrefix + path)
+}
+
+// ConfigEntry is a configuration value.  Origin and Scope are only set by ConfigList; Origin describes where the
+// value was read from, such as "file:.git/config" or "command line:".  Keys are reported as git normalizes them, with
+// the section and name in lower case.
+type ConfigEntry struct {
+	Key    string
+	Value  string
+	Origin string
+	Scope  ConfigScope
+}
+
+// The minimum git version which supports 'git config --show-scope'.
+const configShowScopeMajor, configShowScopeMinor = 2, 26
+
+// configArgs returns the git config command line for scope.
+func configArgs(scope ConfigScope) []string {
+	cmdArr := []string{"git", "config"}
+	if strings.HasPrefix(string(scope), configFileScopePrefix) {
+		return append(cmdArr, "--file", strings.TrimPrefix(string(scope), configFileScopePrefix))
+	}
+	if scope != ConfigScopeAll {
+		cmdArr = append(cmdArr, "--"+string(scope))
+	}
+	return cmdArr
+}
+
+func ConfigList(exec Executor, scope ConfigScope) ([]ConfigEntry, error) {
+	// With -z each value is reported as NUL terminated fields, the key and value being separated by a newline, or the
+	// newline and value being omitted for keys given without a value:
+	// <scope> NUL <origin> NUL <key> LF <value> NUL
+	// Git older than 2.26 cannot report the scope, which is then left empty.
+	version, err := GetGitVersion(exec)
+	if err != nil {
+		return nil, err
+	}
+	cmdArr := append(configArgs(scope), "--null", "--list", "--show-origin")
+	showScope := version.AtLeast(configShowScopeMajor, configShowScopeMinor)
+	if showScope {
+		cmdArr = append(cmdArr, "--show-scope")
+	}
+	out, err := runAndGetOutput(exec, cmdArr)
+	if err != nil {
+		return nil, err
+	}
+	records := splitNul(out)
+	fields := 2
+	if showScope {
+		fields = 3
+	}
+	if len(records)%fields != 0 {
+		return nil, errors.New("Unrecognized config list output: " + string(out))
+	}
+	entries := []ConfigEntry{}
+	for i := 0; i < len(records); i += fields {
+		entry := parseConfigKeyValue(records[i+fields-1])
+		entry.Origin = records[i+fields-2]
+		if showScope {
+			entry.Scope = ConfigScope(records[i])
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// parseConfigKeyValue parses a key and value separated by a newline, as reported by config with -z.
+func parseConfigKeyValue(record string) ConfigEntry {
+	key, value := record, ""
+	if newline := strings.IndexByte(record, '\n'); newline >= 0 {
+		key, value = record[:newline], record[newline+1:]
+	}
+	return ConfigEntry{Key: key, Value: value}
+}
+
+// configKeyValues parses the output of config with -z for options which report keys and values.
+func configKeyValues(output []byte) []ConfigEntry {
+	entries := []ConfigEntry{}
+	for _, record := range splitNul(output) {
+		entries = append(entries, parseConfigKeyValue(record))
+	}
+	return entries
+}
+
+var reForInvalidConfigKey = regexp.MustCompile(`invalid key|key does not contain a section|invalid section`)
+
+// configError translates a failure of git config concerning key into the matching sentinel error, if any.  Git exits
+// 1 both for keys which are not set and, with an explanation on stderr, for invalid keys.
+func configError(key string, err error) error {
+	var gitErr *GitError
+	if !errors.As(err, &gitErr) {
+		return err
+	}
+	switch {
+	case strings.Contains(gitErr.Stderr, "has multiple values"):
+		return fmt.Errorf("%w: %q: %v", ErrConfigMultipleValues, key, err)
+	case gitErr.ExitCode == 2 || reForInvalidConfigKey.MatchString(gitErr.Stderr):
+		return fmt.Errorf("%w: %q: %v", ErrInvalidConfigKey, key, err)
+	case gitErr.ExitCode == 1:
+		return fmt.Errorf("%w: %q", ErrConfigKeyNotFound, key)
+	}
+	return err
+}
+
+// runConfigQuery runs a config command which exits 1 when nothing matched, returning nil output in that case.
+func runConfigQuery(exec Executor, cmdArr []string, key string) ([]byte, error) {
+	out, err := runAndGetOutput(exec, cmdArr)
+	if err != nil {
+		err = configError(key, err)
+		if errors.Is(err, ErrConfigKeyNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+func ConfigGetAll(exec Executor, scope ConfigScope, key string) ([]string, error) {
+	// Returns every value of a multi-valued key in the order git reads them, or an empty list when the key is unset.
+	cmdArr := append(configArgs(scope), "--null", "--get-all", "--", key)
+	out, err := runConfigQuery(exec, cmdArr, key)
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{}, splitNul(out)...), nil
+}
+
+func ConfigGetRegexp(exec Executor, scope ConfigScope, pattern string) ([]ConfigEntry, error) {
+	// Returns the values of every key matching the regular expression, such as `^remote\..*\.url$`.
+	cmdArr := append(configArgs(scope), "--null", "--get-regexp", "--", pattern)
+	out, err := runConfigQuery(exec, cmdArr, pattern)
+	if err != nil {
+		return nil, err
+	}
+	return configKeyValues(out), nil
+}
+
+func ConfigGetURLMatch(exec Executor, name string, url string) ([]ConfigEntry, error) {
+	// Returns the values which apply to url from url-specific sections such as http.<url>.*.  When name is a section,
+	// such as "http", every key of the section is returned; when it is a key, such as "http.sslVerify", only that key
+	// is.  Keys are reported without the url, for example http.sslverify.
+	cmdArr := []string{"git", "config", "--null", "--get-urlmatch", "--", name, url}
+	out, err := runConfigQuery(exec, cmdArr, name)
+	if err != nil {
+		return nil, err
+	}
+	if strings.Contains(name, ".") {
+		// For a single key only values are reported.
+		entries := []ConfigEntry{}
+		for _, value := range splitNul(out) {
+			entries = append(entries, ConfigEntry{Key: strings.ToLower(name), Value: value})
+		}
+		return entries, nil
+	}
+	return configKeyValues(out), nil
+}
+
+func ConfigGet(exec Executor, scope ConfigScope, key string) (string, error) {
+	// Returns the value of key, or the last value git read when it has several.  A key which is not set results in an
+	// error matching ErrConfigKeyNotFound.
+	return configGet(exec, scope, key, "")
+}
+
+func ConfigGetOrDefault(exec Executor, scope ConfigScope, key string, defaultValue string) (string, error) {
+	// Like ConfigGet, but returns defaultValue for a key which is not set.
+	value, err := configGet(exec, scope, key, "")
+	if errors.Is(err, ErrConfigKeyNotFound) {
+		return defaultValue, nil
+	}
+	return value, err
+}
+
+// configGet returns the value of key, canonicalized according to valueType when it is set.
+func configGet(exec Executor, scope ConfigScope, key string, valueType string) (string, error) {
+	cmdArr := configArgs(scope)
+	if len(valueType) != 0 {
+		cmdArr = append(cmdArr, "--type="+valueType)
+	}
+	cmdArr = append(cmdArr, "--null", "--get", "--", key)
+	out, err := runAndGetOutput(exec, cmdArr)
+	if err != nil {
+		return "", configError(key, err)
+	}
+	values := splitNul(out)
+	if len(values) == 0 {
+		return "", errors.New("Unrecognized config output: " + string(out))
+	}
+	return values[0], nil
+}
+
+func ConfigGetBool(exec Executor, scope ConfigScope, key string) (bool, error) {
+	// Git accepts values such as yes, on, 1 and keys given without a value as true.
+	value, err := configGet(exec, scope, key, "bool")
+	if err != nil {
+		return false, err
+	}
+	return value == "true", nil
+}
+
+func ConfigGetInt(exec Executor, scope ConfigScope, key string) (int64, error) {
+	// Git applies unit suffixes such as k, m and g.
+	value, err := configGet(exec, scope, key, "int")
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+func ConfigGetPath(exec Executor, scope ConfigScope, key string) (string, error) {
+	// Git expands a leading ~ or ~user.
+	return configGet(exec, scope, key, "path")
+}
+
+func ConfigGetExpiryDate(exec Executor, scope ConfigScope, key string) (time.Time, error) {
+	// Git converts dates, including relative ones such as 2.weeks.ago, to a timestamp.
+	value, err := configGet(exec, scope, key, "expiry-date")
+	if err != nil {
+		return time.Time{}, err
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(seconds, 0), nil
+}
+
+func ConfigSet(exec Executor, scope ConfigScope, key string, value string) error {
+	// Replaces the value of key, failing when the key has several values.
+	cmdArr := append(configArgs(scope), "--", key, value)
+	if _, err := runAndGetOutput(exec, cmdArr); err != nil {
+		return configError(key, err)
+	}
+	return nil
+}
+
+func ConfigAdd(exec Executor, scope ConfigScope, key string, value string) error {
+	// Adds a value to key, keeping any values it already has.
+	cmdArr := append(configArgs(scope), "--add", "--", key, value)
+	if _, err := runAndGetOutput(exec, cmdArr); err != nil {
+		return configError(key, err)
+	}
+	return nil
+}
+
+func ConfigUnset(exec Executor, scope ConfigScope, key string, all bool) error {
+	// Removes the value of key, or every value when all is set.  Without all, git fails when the key has several
+	// values.  Unsetting a key which is not set succeeds.
+	option := "--unset"
+	if all {
+		option = "--unset-all"
+	}
+	cmdArr := append(configArgs(scope), option, "--", key)
+	_, err := runAndGetOutput(exec, cmdArr)
+	if err == nil {
+		return nil
+	}
+	err = configError(key, err)
+	// git config exits 5 when the key was not set, as well as for the several values configError reports.
+	if exitCodeOf(err) == 5 {
+		return nil
+	}
+	return err
+}
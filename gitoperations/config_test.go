@@ -0,0 +1,333 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestConfigList(t *testing.T) {
+	setup()
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls,
+			fakeResponse{stdout: "git version 2.39.5\n"},
+			fakeResponse{stdout: "global\x00file:/home/u/.gitconfig\x00user.name\nSome One\x00" +
+				"local\x00file:.git/config\x00imp.flag\x00" +
+				"command\x00command line:\x00core.pager\nless -R\nx\x00"})
+		entries, err := ConfigList(mockGit, ConfigScopeAll)
+		if err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		expected := []ConfigEntry{
+			{Key: "user.name", Value: "Some One", Origin: "file:/home/u/.gitconfig", Scope: ConfigScopeGlobal},
+			{Key: "imp.flag", Origin: "file:.git/config", Scope: ConfigScopeLocal},
+			{Key: "core.pager", Value: "less -R\nx", Origin: "command line:", Scope: ConfigScopeCommand},
+		}
+		if !reflect.DeepEqual(entries, expected) {
+			t.Fatalf("Expected %+v, but received %+v", expected, entries)
+		}
+		expectedCmd := []string{"git", "config", "--null", "--list", "--show-origin", "--show-scope"}
+		if !reflect.DeepEqual(calls[1], expectedCmd) {
+			t.Fatalf("Expected %v, but received %v", expectedCmd, calls[1])
+		}
+	}
+	{ // Older versions of git cannot report scopes.
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls,
+			fakeResponse{stdout: "git version 2.25.1\n"},
+			fakeResponse{stdout: "file:/etc/gitconfig\x00core.editor\nvi\x00"})
+		entries, err := ConfigList(mockGit, ConfigFileScope("/etc/gitconfig"))
+		if err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		expected := []ConfigEntry{{Key: "core.editor", Value: "vi", Origin: "file:/etc/gitconfig"}}
+		if !reflect.DeepEqual(entries, expected) {
+			t.Fatalf("Expected %+v, but received %+v", expected, entries)
+		}
+		expectedCmd := []string{"git", "config", "--file", "/etc/gitconfig", "--null", "--list", "--show-origin"}
+		if !reflect.DeepEqual(calls[1], expectedCmd) {
+			t.Fatalf("Expected %v, but received %v", expectedCmd, calls[1])
+		}
+	}
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls,
+			fakeResponse{stdout: "git version 2.39.5\n"},
+			fakeResponse{stdout: "local\x00file:.git/config\x00"})
+		if _, err := ConfigList(mockGit, ConfigScopeLocal); err == nil {
+			t.Fatalf("Expected non-nil error")
+		}
+	}
+}
+
+func TestConfigGetAll(t *testing.T) {
+	setup()
+	{
+		calls := [][]string{}
+		values, err := ConfigGetAll(createScriptedExecCommand(&calls, fakeResponse{stdout: "-val\x00two\x00"}),
+			ConfigScopeGlobal, "remote.origin.fetch")
+		if err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		if !reflect.DeepEqual(values, []string{"-val", "two"}) {
+			t.Fatalf("Expected both values, but received %v", values)
+		}
+		expectedCmd := []string{"git", "config", "--global", "--null", "--get-all", "--", "remote.origin.fetch"}
+		if !reflect.DeepEqual(calls[0], expectedCmd) {
+			t.Fatalf("Expected %v, but received %v", expectedCmd, calls[0])
+		}
+	}
+	{ // An unset key has no values.
+		calls := [][]string{}
+		values, err := ConfigGetAll(createScriptedExecCommand(&calls, fakeResponse{exitStatus: 1}), ConfigScopeAll,
+			"no.key")
+		if err != nil || len(values) != 0 || values == nil {
+			t.Fatalf("Expected an empty list, but received %v, %v", values, err)
+		}
+	}
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls,
+			fakeResponse{stderr: "error: key does not contain a section: bad\n", exitStatus: 1})
+		if _, err := ConfigGetAll(mockGit, ConfigScopeAll, "bad"); !errors.Is(err, ErrInvalidConfigKey) {
+			t.Fatalf("Expected ErrInvalidConfigKey, but received %v", err)
+		}
+	}
+	{
+		calls := [][]string{}
+		if _, err := ConfigGetAll(createScriptedExecCommand(&calls, fakeResponse{exitStatus: 3}), ConfigScopeAll,
+			"my.key"); exitCodeOf(err) != 3 {
+			t.Fatalf("Expected exit status 3, but received %v", err)
+		}
+	}
+}
+
+func TestConfigGetRegexpAndURLMatch(t *testing.T) {
+	setup()
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{stdout: "my.key\n-val\x00my.flag\x00"})
+		entries, err := ConfigGetRegexp(mockGit, ConfigScopeLocal, `^my\.`)
+		if err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		expected := []ConfigEntry{{Key: "my.key", Value: "-val"}, {Key: "my.flag"}}
+		if !reflect.DeepEqual(entries, expected) {
+			t.Fatalf("Expected %+v, but received %+v", expected, entries)
+		}
+		expectedCmd := []string{"git", "config", "--local", "--null", "--get-regexp", "--", `^my\.`}
+		if !reflect.DeepEqual(calls[0], expectedCmd) {
+			t.Fatalf("Expected %v, but received %v", expectedCmd, calls[0])
+		}
+	}
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{stdout: "http.proxy\np\x00http.sslverify\nfalse\x00"})
+		entries, err := ConfigGetURLMatch(mockGit, "http", "https://example.com/repo.git")
+		if err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		expected := []ConfigEntry{{Key: "http.proxy", Value: "p"}, {Key: "http.sslverify", Value: "false"}}
+		if !reflect.DeepEqual(entries, expected) {
+			t.Fatalf("Expected %+v, but received %+v", expected, entries)
+		}
+		expectedCmd := []string{"git", "config", "--null", "--get-urlmatch", "--", "http",
+			"https://example.com/repo.git"}
+		if !reflect.DeepEqual(calls[0], expectedCmd) {
+			t.Fatalf("Expected %v, but received %v", expectedCmd, calls[0])
+		}
+	}
+	{ // Only values are reported for a single key.
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{stdout: "false\x00"})
+		entries, err := ConfigGetURLMatch(mockGit, "http.sslVerify", "https://example.com/repo.git")
+		if err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		expected := []ConfigEntry{{Key: "http.sslverify", Value: "false"}}
+		if !reflect.DeepEqual(entries, expected) {
+			t.Fatalf("Expected %+v, but received %+v", expected, entries)
+		}
+	}
+}
+
+func TestConfigGet(t *testing.T) {
+	setup()
+	{
+		calls := [][]string{}
+		value, err := ConfigGet(createScriptedExecCommand(&calls, fakeResponse{stdout: "line one\nline two\x00"}),
+			ConfigScopeLocal, "my.key")
+		if err != nil || value != "line one\nline two" {
+			t.Fatalf("Expected both lines, but received %q, %v", value, err)
+		}
+		expectedCmd := []string{"git", "config", "--local", "--null", "--get", "--", "my.key"}
+		if !reflect.DeepEqual(calls[0], expectedCmd) {
+			t.Fatalf("Expected %v, but received %v", expectedCmd, calls[0])
+		}
+	}
+	{
+		calls := [][]string{}
+		value, err := ConfigGet(createScriptedExecCommand(&calls, fakeResponse{exitStatus: 1}), ConfigScopeAll,
+			"no.key")
+		if !errors.Is(err, ErrConfigKeyNotFound) || value != "" {
+			t.Fatalf("Expected ErrConfigKeyNotFound, but received %q, %v", value, err)
+		}
+	}
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls,
+			fakeResponse{stderr: "error: invalid key: my.bad key\n", exitStatus: 1})
+		if _, err := ConfigGet(mockGit, ConfigScopeAll, "my.bad key"); !errors.Is(err, ErrInvalidConfigKey) {
+			t.Fatalf("Expected ErrInvalidConfigKey, but received %v", err)
+		}
+	}
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls,
+			fakeResponse{stderr: "fatal: bad config line 3 in file .git/config\n", exitStatus: 128})
+		if _, err := ConfigGet(mockGit, ConfigScopeAll, "my.key"); exitCodeOf(err) != 128 {
+			t.Fatalf("Expected exit status 128, but received %v", err)
+		}
+	}
+}
+
+func TestConfigGetOrDefault(t *testing.T) {
+	setup()
+	{
+		calls := [][]string{}
+		value, err := ConfigGetOrDefault(createScriptedExecCommand(&calls, fakeResponse{exitStatus: 1}),
+			ConfigScopeAll, "pull.rebase", "false")
+		if err != nil || value != "false" {
+			t.Fatalf("Expected the default, but received %q, %v", value, err)
+		}
+	}
+	{
+		calls := [][]string{}
+		value, err := ConfigGetOrDefault(createScriptedExecCommand(&calls, fakeResponse{stdout: "true\x00"}),
+			ConfigScopeAll, "pull.rebase", "false")
+		if err != nil || value != "true" {
+			t.Fatalf("Expected the value, but received %q, %v", value, err)
+		}
+	}
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls,
+			fakeResponse{stderr: "error: key does not contain a section: pull\n", exitStatus: 1})
+		if _, err := ConfigGetOrDefault(mockGit, ConfigScopeAll, "pull", "x"); !errors.Is(err, ErrInvalidConfigKey) {
+			t.Fatalf("Expected ErrInvalidConfigKey, but received %v", err)
+		}
+	}
+}
+
+func TestConfigTypedGetters(t *testing.T) {
+	setup()
+	{
+		calls := [][]string{}
+		value, err := ConfigGetBool(createScriptedExecCommand(&calls, fakeResponse{stdout: "true\x00"}),
+			ConfigScopeAll, "pull.rebase")
+		if err != nil || !value {
+			t.Fatalf("Expected true, but received %v, %v", value, err)
+		}
+		expectedCmd := []string{"git", "config", "--type=bool", "--null", "--get", "--", "pull.rebase"}
+		if !reflect.DeepEqual(calls[0], expectedCmd) {
+			t.Fatalf("Expected %v, but received %v", expectedCmd, calls[0])
+		}
+	}
+	{
+		calls := [][]string{}
+		value, err := ConfigGetInt(createScriptedExecCommand(&calls, fakeResponse{stdout: "2048\x00"}),
+			ConfigScopeWorktree, "core.bigFileThreshold")
+		if err != nil || value != 2048 {
+			t.Fatalf("Expected 2048, but received %v, %v", value, err)
+		}
+		expectedCmd := []string{"git", "config", "--worktree", "--type=int", "--null", "--get", "--",
+			"core.bigFileThreshold"}
+		if !reflect.DeepEqual(calls[0], expectedCmd) {
+			t.Fatalf("Expected %v, but received %v", expectedCmd, calls[0])
+		}
+	}
+	{
+		calls := [][]string{}
+		value, err := ConfigGetPath(createScriptedExecCommand(&calls, fakeResponse{stdout: "/home/u/hooks\x00"}),
+			ConfigScopeAll, "core.hooksPath")
+		if err != nil || value != "/home/u/hooks" {
+			t.Fatalf("Expected /home/u/hooks, but received %v, %v", value, err)
+		}
+	}
+	{
+		calls := [][]string{}
+		value, err := ConfigGetExpiryDate(createScriptedExecCommand(&calls, fakeResponse{stdout: "1791115953\x00"}),
+			ConfigScopeAll, "gc.reflogExpire")
+		if err != nil || !value.Equal(time.Unix(1791115953, 0)) {
+			t.Fatalf("Expected the timestamp, but received %v, %v", value, err)
+		}
+	}
+	{
+		calls := [][]string{}
+		if _, err := ConfigGetBool(createScriptedExecCommand(&calls, fakeResponse{exitStatus: 128}),
+			ConfigScopeAll, "pull.rebase"); exitCodeOf(err) != 128 {
+			t.Fatalf("Expected exit status 128, but received %v", err)
+		}
+	}
+}
+
+func TestConfigEditing(t *testing.T) {
+	setup()
+	type cmd struct {
+		f        func(exec Executor) error
+		expected []string
+	}
+	functions := []cmd{
+		{func(exec Executor) error { return ConfigSet(exec, ConfigScopeAll, "user.name", "-x") },
+			[]string{"git", "config", "--", "user.name", "-x"}},
+		{func(exec Executor) error { return ConfigSet(exec, ConfigScopeSystem, "core.editor", "vi") },
+			[]string{"git", "config", "--system", "--", "core.editor", "vi"}},
+		{func(exec Executor) error {
+			return ConfigAdd(exec, ConfigFileScope(".gitmodules"), "remote.origin.fetch", "+refs/x:refs/y")
+		}, []string{"git", "config", "--file", ".gitmodules", "--add", "--", "remote.origin.fetch", "+refs/x:refs/y"}},
+		{func(exec Executor) error { return ConfigUnset(exec, ConfigScopeGlobal, "user.name", false) },
+			[]string{"git", "config", "--global", "--unset", "--", "user.name"}},
+		{func(exec Executor) error { return ConfigUnset(exec, ConfigScopeLocal, "remote.origin.fetch", true) },
+			[]string{"git", "config", "--local", "--unset-all", "--", "remote.origin.fetch"}},
+	}
+	for _, fn := range functions {
+		calls := [][]string{}
+		if err := fn.f(createScriptedExecCommand(&calls, fakeResponse{})); err != nil {
+			t.Errorf("Expected nil error, but received %v", err)
+		}
+		if !reflect.DeepEqual(calls[0], fn.expected) {
+			t.Errorf("Expected %v, but received %v", fn.expected, calls[0])
+		}
+		calls = [][]string{}
+		if err := fn.f(createScriptedExecCommand(&calls, fakeResponse{exitStatus: 4})); exitCodeOf(err) != 4 {
+			t.Errorf("Expected exit status 4, but received %v", err)
+		}
+	}
+
+	// Unsetting a key which is not set succeeds.
+	calls := [][]string{}
+	if err := ConfigUnset(createScriptedExecCommand(&calls, fakeResponse{exitStatus: 5}), ConfigScopeAll, "no.key",
+		false); err != nil {
+		t.Errorf("Expected nil error, but received %v", err)
+	}
+
+	multipleValues := fakeResponse{stderr: "warning: my.m has multiple values\n", exitStatus: 5}
+	if err := ConfigUnset(createScriptedExecCommand(&calls, multipleValues), ConfigScopeAll, "my.m",
+		false); !errors.Is(err, ErrConfigMultipleValues) {
+		t.Errorf("Expected ErrConfigMultipleValues, but received %v", err)
+	}
+	if err := ConfigSet(createScriptedExecCommand(&calls, multipleValues), ConfigScopeAll, "my.m",
+		"3"); !errors.Is(err, ErrConfigMultipleValues) {
+		t.Errorf("Expected ErrConfigMultipleValues, but received %v", err)
+	}
+	invalidKey := fakeResponse{stderr: "error: key does not contain a section: bad key\n", exitStatus: 2}
+	if err := ConfigSet(createScriptedExecCommand(&calls, invalidKey), ConfigScopeAll, "bad key",
+		"v"); !errors.Is(err, ErrInvalidConfigKey) {
+		t.Errorf("Expected ErrInvalidConfigKey, but received %v", err)
+	}
+}
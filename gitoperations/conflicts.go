@@ -0,0 +1,210 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrConflict matches, via errors.Is, failures caused by an operation stopping on conflicts which need resolving.
+var ErrConflict = errors.New("Operation stopped on conflicts")
+
+// ConflictSide identifies one of the versions of a path recorded in the index while a merge, rebase or cherry-pick is
+// stopped on a conflict.  The values correspond to git's index stage numbers.
+type ConflictSide int
+
+const (
+	ConflictBase   ConflictSide = 1
+	ConflictOurs   ConflictSide = 2
+	ConflictTheirs ConflictSide = 3
+)
+
+func (side ConflictSide) String() string {
+	switch side {
+	case ConflictBase:
+		return "base"
+	case ConflictOurs:
+		return "ours"
+	case ConflictTheirs:
+		return "theirs"
+	}
+	return "stage " + strconv.Itoa(int(side))
+}
+
+// isValid reports whether side is ConflictBase, ConflictOurs or ConflictTheirs.
+func (side ConflictSide) isValid() bool {
+	return side == ConflictBase || side == ConflictOurs || side == ConflictTheirs
+}
+
+// ConflictVersion is a single version of a conflicted path as recorded in the index.
+type ConflictVersion struct {
+	Mode     string
+	ObjectID ObjectID
+}
+
+// Conflict describes an unmerged path.  A nil version means the path does not exist on that side, for example Base is
+// nil when both sides added the path and Theirs is nil when they deleted it.
+type Conflict struct {
+	Path   string
+	Base   *ConflictVersion
+	Ours   *ConflictVersion
+	Theirs *ConflictVersion
+}
+
+// Version returns the version recorded for the given side, or nil when the path is absent on that side.
+func (conflict Conflict) Version(side ConflictSide) *ConflictVersion {
+	switch side {
+	case ConflictBase:
+		return conflict.Base
+	case ConflictOurs:
+		return conflict.Ours
+	case ConflictTheirs:
+		return conflict.Theirs
+	}
+	return nil
+}
+
+// ConflictResolution describes how ResolveConflict should resolve a path.  When Content is non-nil it becomes the
+// resolved file; otherwise the version recorded for Side is taken, and a side on which the path is absent resolves the
+// conflict by deleting the path.  Side must then be ConflictBase, ConflictOurs or ConflictTheirs, so the zero value is
+// rejected.
+type ConflictResolution struct {
+	Side    ConflictSide
+	Content []byte
+	// Mode applies to Content only.  It defaults to the mode of our version, then theirs, then 100644.
+	Mode string
+}
+
+func ListConflicts(exec Executor) ([]Conflict, error) {
+	// Parses 'git ls-files -u -z', whose records look like:
+	// <mode> SP <object> SP <stage> TAB <path> NUL
+	// Paths are reported relative to the current directory, in index order, so the stages of a path are adjacent.
+	cmdArr := []string{"git", "ls-files", "-u", "-z"}
+	out, err := runAndGetOutput(exec, cmdArr)
+	if err != nil {
+		return nil, err
+	}
+	conflicts := []Conflict{}
+	for _, record := range splitNul(out) {
+		tab := strings.IndexByte(record, '\t')
+		if tab < 0 {
+			return nil, errors.New("Unrecognized ls-files output: " + record)
+		}
+		fields := strings.Fields(record[:tab])
+		if len(fields) != 3 {
+			return nil, errors.New("Unrecognized ls-files output: " + record)
+		}
+		stage, err := strconv.Atoi(fields[2])
+		if err != nil || stage < int(ConflictBase) || stage > int(ConflictTheirs) {
+			return nil, errors.New("Unrecognized stage in ls-files output: " + record)
+		}
+		path := record[tab+1:]
+		if len(conflicts) == 0 || conflicts[len(conflicts)-1].Path != path {
+			conflicts = append(conflicts, Conflict{Path: path})
+		}
+		conflict := &conflicts[len(conflicts)-1]
+		oid, err := ParseObjectID(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		version := &ConflictVersion{Mode: fields[0], ObjectID: oid}
+		switch ConflictSide(stage) {
+		case ConflictBase:
+			conflict.Base = version
+		case ConflictOurs:
+			conflict.Ours = version
+		case ConflictTheirs:
+			conflict.Theirs = version
+		}
+	}
+	return conflicts, nil
+}
+
+func ReadConflictVersion(exec Executor, path string, side ConflictSide) ([]byte, error) {
+	// Returns the content of one version of a conflicted path.  The "./" keeps the index path relative to the current
+	// directory, matching the paths returned by ListConflicts.
+	if !side.isValid() {
+		return nil, fmt.Errorf("Invalid conflict side %s for %s", side, path)
+	}
+	cmdArr := []string{"git", "cat-file", "blob", fmt.Sprintf(":%d:./%s", side, path)}
+	return runAndGetOutput(exec, cmdArr)
+}
+
+func ResolveConflict(exec Executor, path string, resolution ConflictResolution) error {
+	// Writes the resolution to both the working tree and the index, which stages it and removes the unmerged entries.
+	if resolution.Content == nil && !resolution.Side.isValid() {
+		return fmt.Errorf("Invalid conflict side %s for %s: a side is required when no content is given",
+			resolution.Side, path)
+	}
+	conflicts, err := ListConflicts(exec)
+	if err != nil {
+		return err
+	}
+	var conflict *Conflict
+	for i := range conflicts {
+		if conflicts[i].Path == path {
+			conflict = &conflicts[i]
+			break
+		}
+	}
+	if conflict == nil {
+		return errors.New("Path is not in conflict: " + path)
+	}
+
+	if resolution.Content == nil {
+		if conflict.Version(resolution.Side) == nil {
+			cmdArr := []string{"git", "rm", "--quiet", "--force", "--", path}
+			_, err := runAndGetOutput(exec, cmdArr)
+			return err
+		}
+		cmdArr := []string{"git", "checkout-index", "--force", fmt.Sprintf("--stage=%d", resolution.Side), "--", path}
+		if _, err := runAndGetOutput(exec, cmdArr); err != nil {
+			return err
+		}
+		return MarkResolved(exec, []string{path})
+	}
+
+	mode := resolution.Mode
+	if len(mode) == 0 {
+		mode = "100644"
+		if conflict.Ours != nil {
+			mode = conflict.Ours.Mode
+		} else if conflict.Theirs != nil {
+			mode = conflict.Theirs.Mode
+		}
+	}
+	cmdArr := []string{"git", "hash-object", "-w", "--stdin", "--path=" + path}
+	out, err := runWithInputAndGetOutput(exec, cmdArr, resolution.Content)
+	if err != nil {
+		return err
+	}
+	objectID := strings.TrimSpace(string(out))
+	// Unlike the other commands used here, update-index --cacheinfo takes a path relative to the top level.
+	cmdArr = []string{"git", "rev-parse", "--show-prefix"}
+	out, err = runAndGetOutput(exec, cmdArr)
+	if err != nil {
+		return err
+	}
+	prefix := strings.TrimSuffix(string(out), "\n")
+	cmdArr = []string{"git", "update-index", "--cacheinfo", fmt.Sprintf("%s,%s,%s%s", mode, objectID, prefix, path)}
+	if _, err := runAndGetOutput(exec, cmdArr); err != nil {
+		return err
+	}
+	cmdArr = []string{"git", "checkout-index", "--force", "--", path}
+	_, err = runAndGetOutput(exec, cmdArr)
+	return err
+}
+
+func MarkResolved(exec Executor, paths []string) error {
+	// Stages the working tree state of each path, including deletions, as its resolution.
+	if len(paths) == 0 {
+		return nil
+	}
+	cmdArr := append([]string{"git", "add", "--all", "--"}, paths...)
+	_, err := runAndGetOutput(exec, cmdArr)
+	return err
+}
@@ -0,0 +1,197 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const lsFilesUnmerged = "100644 78981922613b2afb6025042ff6bd878ac1994e85 1\tdir/both modified\x00" +
+	"100644 f2ad6c76f0115a6ba5b00456a849810e7ec0af20 2\tdir/both modified\x00" +
+	"100755 61780798228d17af2d34fce4cfbdf35556832472 3\tdir/both modified\x00" +
+	"100644 587be6b4c3f93f93c489c0111bba5596147a26cb 1\tdeleted by them\x00" +
+	"100644 f2ad6c76f0115a6ba5b00456a849810e7ec0af20 2\tdeleted by them\x00"
+
+func TestListConflicts(t *testing.T) {
+	setup()
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{stdout: lsFilesUnmerged})
+		conflicts, err := ListConflicts(mockGit)
+		if err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		expected := []Conflict{
+			{
+				Path:   "dir/both modified",
+				Base:   &ConflictVersion{"100644", "78981922613b2afb6025042ff6bd878ac1994e85"},
+				Ours:   &ConflictVersion{"100644", "f2ad6c76f0115a6ba5b00456a849810e7ec0af20"},
+				Theirs: &ConflictVersion{"100755", "61780798228d17af2d34fce4cfbdf35556832472"},
+			},
+			{
+				Path: "deleted by them",
+				Base: &ConflictVersion{"100644", "587be6b4c3f93f93c489c0111bba5596147a26cb"},
+				Ours: &ConflictVersion{"100644", "f2ad6c76f0115a6ba5b00456a849810e7ec0af20"},
+			},
+		}
+		if !reflect.DeepEqual(conflicts, expected) {
+			t.Fatalf("Expected %+v, but received %+v", expected, conflicts)
+		}
+		if strings.Join(calls[0], " ") != "git ls-files -u -z" {
+			t.Fatalf("Unexpected command line: %v", calls[0])
+		}
+	}
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{})
+		conflicts, err := ListConflicts(mockGit)
+		if err != nil || len(conflicts) != 0 {
+			t.Fatalf("Expected no conflicts, but received %v, %v", conflicts, err)
+		}
+	}
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{stdout: "garbage\x00"})
+		if _, err := ListConflicts(mockGit); err == nil {
+			t.Fatalf("Expected non-nil error")
+		}
+	}
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls,
+			fakeResponse{stderr: "fatal: not a git repository\n", exitStatus: 128})
+		_, err := ListConflicts(mockGit)
+		if err == nil {
+			t.Fatalf("Expected non-nil error")
+		}
+		if exitCodeOf(err) != 128 || !strings.HasSuffix(err.Error(), "fatal: not a git repository") {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+}
+
+func TestReadConflictVersion(t *testing.T) {
+	setup()
+	calls := [][]string{}
+	mockGit := createScriptedExecCommand(&calls, fakeResponse{stdout: "their content\n"})
+	content, err := ReadConflictVersion(mockGit, "dir/file", ConflictTheirs)
+	if err != nil {
+		t.Fatalf("Expected nil error, but received %v", err)
+	}
+	if string(content) != "their content\n" {
+		t.Fatalf("Unexpected content %q", content)
+	}
+	expected := []string{"git", "cat-file", "blob", ":3:./dir/file"}
+	if !reflect.DeepEqual(calls[0], expected) {
+		t.Fatalf("Expected %v, but received %v", expected, calls[0])
+	}
+	// Stage 0 is the merged entry, which is not a side of the conflict.
+	for _, side := range []ConflictSide{0, 4} {
+		if _, err := ReadConflictVersion(mockGit, "dir/file", side); err == nil {
+			t.Fatalf("Expected non-nil error for %s", side)
+		}
+	}
+	if len(calls) != 1 {
+		t.Fatalf("Expected no further commands, but received %v", calls[1:])
+	}
+}
+
+func TestResolveConflict(t *testing.T) {
+	setup()
+	{ // Taking a side which has the path checks that version out and stages it.
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{stdout: lsFilesUnmerged}, fakeResponse{})
+		err := ResolveConflict(mockGit, "dir/both modified", ConflictResolution{Side: ConflictBase})
+		if err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		expected := [][]string{
+			{"git", "ls-files", "-u", "-z"},
+			{"git", "checkout-index", "--force", "--stage=1", "--", "dir/both modified"},
+			{"git", "add", "--all", "--", "dir/both modified"},
+		}
+		if !reflect.DeepEqual(calls, expected) {
+			t.Fatalf("Expected %v, but received %v", expected, calls)
+		}
+	}
+	{ // Taking a side on which the path was deleted removes it.
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{stdout: lsFilesUnmerged}, fakeResponse{})
+		err := ResolveConflict(mockGit, "deleted by them", ConflictResolution{Side: ConflictTheirs})
+		if err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		expected := []string{"git", "rm", "--quiet", "--force", "--", "deleted by them"}
+		if len(calls) != 2 || !reflect.DeepEqual(calls[1], expected) {
+			t.Fatalf("Expected %v, but received %v", expected, calls)
+		}
+	}
+	{ // Without content the side must be valid, rather than an absent side which would delete the path.
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{stdout: lsFilesUnmerged}, fakeResponse{})
+		for _, resolution := range []ConflictResolution{{}, {Side: 4}} {
+			if err := ResolveConflict(mockGit, "dir/both modified", resolution); err == nil {
+				t.Fatalf("Expected non-nil error for %+v", resolution)
+			}
+		}
+		if len(calls) != 0 {
+			t.Fatalf("Expected no commands, but received %v", calls)
+		}
+	}
+	{ // Explicit content is written to the index and then the working tree, keeping our mode.
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls,
+			fakeResponse{stdout: lsFilesUnmerged},
+			fakeResponse{stdout: "0a1b2c3d4e5f60718293a4b5c6d7e8f901234567\n"},
+			fakeResponse{stdout: "sub/\n"},
+			fakeResponse{})
+		err := ResolveConflict(mockGit, "dir/both modified", ConflictResolution{Content: []byte("merged\n")})
+		if err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		expected := [][]string{
+			{"git", "ls-files", "-u", "-z"},
+			{"git", "hash-object", "-w", "--stdin", "--path=dir/both modified"},
+			{"git", "rev-parse", "--show-prefix"},
+			{"git", "update-index", "--cacheinfo",
+				"100644,0a1b2c3d4e5f60718293a4b5c6d7e8f901234567,sub/dir/both modified"},
+			{"git", "checkout-index", "--force", "--", "dir/both modified"},
+		}
+		if !reflect.DeepEqual(calls, expected) {
+			t.Fatalf("Expected %v, but received %v", expected, calls)
+		}
+	}
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{stdout: lsFilesUnmerged})
+		err := ResolveConflict(mockGit, "not conflicted", ConflictResolution{Side: ConflictOurs})
+		if err == nil || err.Error() != "Path is not in conflict: not conflicted" {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+}
+
+func TestMarkResolved(t *testing.T) {
+	setup()
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{})
+		if err := MarkResolved(mockGit, []string{"a", "-b"}); err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		expected := []string{"git", "add", "--all", "--", "a", "-b"}
+		if !reflect.DeepEqual(calls[0], expected) {
+			t.Fatalf("Expected %v, but received %v", expected, calls[0])
+		}
+	}
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{})
+		if err := MarkResolved(mockGit, nil); err != nil || len(calls) != 0 {
+			t.Fatalf("Expected no git invocation, but received %v, %v", calls, err)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrAuthenticationRequired matches, via errors.Is, failures caused by a remote requiring credentials which were
+// missing or rejected.
+var ErrAuthenticationRequired = errors.New("authentication required")
+
+// CredentialRequest identifies the remote credentials are needed for, in the terms of git's credential protocol.
+type CredentialRequest struct {
+	Protocol string
+	Host     string
+	Path     string
+	// Username is set when the remote URL names the user.
+	Username string
+}
+
+// Credential is the answer to a CredentialRequest.  The zero Credential means the provider has no credentials for the
+// remote, in which case git falls back to the user's own configuration.
+type Credential struct {
+	Username string
+	Password string
+}
+
+// CredentialProvider supplies credentials for HTTP(S) remotes programmatically.  A controller consults it before each
+// network operation, for the URL of the remote the operation contacts.
+type CredentialProvider interface {
+	GetCredential(request CredentialRequest) (Credential, error)
+}
+
+// CredentialProviderFunc adapts a function to a CredentialProvider.
+type CredentialProviderFunc func(request CredentialRequest) (Credential, error)
+
+func (fn CredentialProviderFunc) GetCredential(request CredentialRequest) (Credential, error) {
+	return fn(request)
+}
+
+// The prefixes of the variables through which each credential helper shim receives its credential and the remote it
+// is for.  Passing credentials in the environment of the git process keeps them out of command lines, which other
+// users can see and which get traced.
+const (
+	credentialUsernameVariable = "GITOPERATIONS_CREDENTIAL_USERNAME"
+	credentialPasswordVariable = "GITOPERATIONS_CREDENTIAL_PASSWORD"
+	credentialProtocolVariable = "GITOPERATIONS_CREDENTIAL_PROTOCOL"
+	credentialHostVariable     = "GITOPERATIONS_CREDENTIAL_HOST"
+	credentialPathVariable     = "GITOPERATIONS_CREDENTIAL_PATH"
+)
+
+// remoteCredential is a credential together with the remote it was obtained for.
+type remoteCredential struct {
+	request    CredentialRequest
+	credential Credential
+}
+
+// credentialHelperShim returns a credential helper which answers "get" requests with the credential in the variables
+// ending in suffix, but only for the remote the credential was obtained for.  Git also asks it about the other
+// remotes an operation contacts, HTTP redirect targets and the remotes of submodules, which must not receive the
+// credential.  Git only sends a path with credential.useHttpPath, in which case it must match too.
+func credentialHelperShim(suffix string) string {
+	return `!f() { test "$1" = get || return 0; p= h= q=; ` +
+		`while IFS= read -r l && test -n "$l"; do case "$l" in ` +
+		`protocol=*) p="${l#protocol=}";; host=*) h="${l#host=}";; path=*) q="${l#path=}";; esac; done; ` +
+		`test "$p" = "$` + credentialProtocolVariable + suffix + `" && ` +
+		`test "$h" = "$` + credentialHostVariable + suffix + `" && ` +
+		`{ test -z "$q" || test "$q" = "$` + credentialPathVariable + suffix + `"; } && ` +
+		`printf 'username=%s\npassword=%s\n' "$` + credentialUsernameVariable + suffix + `" ` +
+		`"$` + credentialPasswordVariable + suffix + `"; }; f`
+}
+
+// withCredentials wraps executor so that git authenticates to the remote each request describes with its credential,
+// through a shim per credential.  Git asks the shims in turn until one answers.  The user's credential helpers are
+// disabled by the empty credential.helper value which precedes the shims.
+func withCredentials(executor Executor, credentials []remoteCredential) Executor {
+	args := []string{"-c", "credential.helper="}
+	env := []string{}
+	shims := 0
+	for _, remote := range credentials {
+		if remote.credential == (Credential{}) {
+			continue
+		}
+		shims++
+		suffix := "_" + strconv.Itoa(shims)
+		args = append(args, "-c", "credential.helper="+credentialHelperShim(suffix))
+		env = append(env,
+			credentialUsernameVariable+suffix+"="+remote.credential.Username,
+			credentialPasswordVariable+suffix+"="+remote.credential.Password,
+			credentialProtocolVariable+suffix+"="+remote.request.Protocol,
+			credentialHostVariable+suffix+"="+remote.request.Host,
+			credentialPathVariable+suffix+"="+remote.request.Path)
+	}
+	if shims == 0 {
+		return executor
+	}
+	return func(name string, cmdArgs ...string) *exec.Cmd {
+		if name == "git" {
+			cmdArgs = append(append([]string{}, args...), cmdArgs...)
+		}
+		cmd := executor(name, cmdArgs...)
+		if cmd.Env == nil {
+			cmd.Env = os.Environ()
+		}
+		cmd.Env = append(cmd.Env, env...)
+		return cmd
+	}
+}
+
+func getRemoteURL(exec Executor, remoteOrURL string) (string, error) {
+	// Expands a remote name, or the default remote when remoteOrURL is empty, into the URL git will contact,
+	// applying any url.<base>.insteadOf rewriting.  URLs are returned after rewriting.
+	cmdArr := []string{"git", "ls-remote", "--get-url"}
+	if len(remoteOrURL) != 0 {
+		cmdArr = append(cmdArr, "--", remoteOrURL)
+	}
+	out, err := runAndGetOutput(exec, cmdArr)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func getRemotePushURLs(exec Executor, remote string) ([]string, error) {
+	// Expands a remote name into the URLs git pushes to: every remote.<name>.pushurl, or else remote.<name>.url, with
+	// url.<base>.pushInsteadOf and url.<base>.insteadOf rewriting applied as for a push.  Names which are not
+	// configured remotes, such as paths, make git exit with status 2, and are expanded like getRemoteURL does.
+	cmdArr := []string{"git", "remote", "get-url", "--push", "--all", "--", remote}
+	out, err := runAndGetOutput(exec, cmdArr)
+	if exitCodeOf(err) == 2 {
+		url, err := getRemoteURL(exec, remote)
+		return []string{url}, err
+	}
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(out)), nil
+}
+
+// makeCredentialRequest describes rawURL as a CredentialRequest, returning false for URLs which do not use HTTP(S).
+func makeCredentialRequest(rawURL string) (CredentialRequest, bool) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return CredentialRequest{}, false
+	}
+	request := CredentialRequest{
+		Protocol: parsed.Scheme,
+		Host:     parsed.Host,
+		Path:     strings.TrimPrefix(parsed.Path, "/"),
+	}
+	if parsed.User != nil {
+		request.Username = parsed.User.Username()
+	}
+	return request, true
+}
+
+var reForAuthenticationFailure = regexp.MustCompile(`terminal prompts disabled|could not read (Username|Password)|` +
+	`Authentication failed|Invalid username or password|Permission denied \(publickey|` +
+	`The requested URL returned error: 40[13]`)
+
+// isAuthenticationFailure reports whether git's stderr shows that it failed for want of valid credentials.
+func isAuthenticationFailure(stderr string) bool {
+	return reForAuthenticationFailure.MatchString(stderr)
+}
+
+// shellQuote quotes s as a single word for the POSIX shell git runs commands such as GIT_SSH_COMMAND with.
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
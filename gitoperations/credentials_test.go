@@ -0,0 +1,275 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMakeCredentialRequest(t *testing.T) {
+	request, ok := makeCredentialRequest("https://bob@example.com:8443/team/repo.git")
+	expected := CredentialRequest{Protocol: "https", Host: "example.com:8443", Path: "team/repo.git", Username: "bob"}
+	if !ok || request != expected {
+		t.Fatalf("Expected %+v, but received %+v, %v", expected, request, ok)
+	}
+	for _, url := range []string{"git@example.com:team/repo.git", "ssh://example.com/repo.git", "/srv/repo.git"} {
+		if _, ok := makeCredentialRequest(url); ok {
+			t.Errorf("Expected no credential request for %s", url)
+		}
+	}
+}
+
+func TestWithCredentials(t *testing.T) {
+	request := CredentialRequest{Protocol: "https", Host: "example.com", Path: "team/repo.git"}
+	executor := withCredentials(exec.Command, []remoteCredential{
+		{request: CredentialRequest{Protocol: "https", Host: "none.example.com"}},
+		{request: request, credential: Credential{Username: "bob", Password: "s3cret"}},
+	})
+	cmd := executor("git", "fetch", "origin")
+	expectedArgs := []string{"git", "-c", "credential.helper=", "-c", "credential.helper=" + credentialHelperShim("_1"),
+		"fetch", "origin"}
+	if !reflect.DeepEqual(cmd.Args, expectedArgs) {
+		t.Fatalf("Expected %v, but received %v", expectedArgs, cmd.Args)
+	}
+	env := strings.Join(cmd.Env, "\n")
+	if !strings.Contains(env, credentialUsernameVariable+"_1=bob") ||
+		!strings.Contains(env, credentialPasswordVariable+"_1=s3cret") ||
+		!strings.Contains(env, credentialHostVariable+"_1=example.com") {
+		t.Fatalf("Expected the credential in the environment")
+	}
+	if strings.Contains(strings.Join(cmd.Args, " "), "s3cret") {
+		t.Fatalf("The password must not appear on the command line")
+	}
+	cmd = withCredentials(exec.Command, []remoteCredential{{request: request}})("git", "fetch")
+	if len(cmd.Args) != 2 || cmd.Env != nil {
+		t.Fatalf("Expected an empty credential to leave the command alone, but received %v", cmd.Args)
+	}
+}
+
+func TestCredentialHelperShim(t *testing.T) {
+	// Runs the shims through git, each of which must answer only for the remote its credential is for.
+	executor := withCredentials(exec.Command, []remoteCredential{
+		{request: CredentialRequest{Protocol: "https", Host: "example.com", Path: "team/repo.git"},
+			credential: Credential{Username: "bob", Password: "s3cret"}},
+		{request: CredentialRequest{Protocol: "https", Host: "push.example.com", Path: "team/repo.git"},
+			credential: Credential{Username: "alice", Password: "pushing"}},
+	})
+	fill := func(input string, config ...string) string {
+		cmd := executor("git", append(config, "credential", "fill")...)
+		cmd.Env = append(cmd.Env, "GIT_TERMINAL_PROMPT=0", "GIT_ASKPASS=", "SSH_ASKPASS=")
+		cmd.Stdin = strings.NewReader(input)
+		out, _ := cmd.Output()
+		return string(out)
+	}
+	if out := fill("protocol=https\nhost=example.com\n\n"); !strings.Contains(out, "password=s3cret") {
+		t.Fatalf("Expected the credential for example.com, but received %q", out)
+	}
+	if out := fill("protocol=https\nhost=push.example.com\n\n"); !strings.Contains(out, "password=pushing") {
+		t.Fatalf("Expected the credential for push.example.com, but received %q", out)
+	}
+	for _, input := range []string{
+		"protocol=https\nhost=evil.example.net\n\n",
+		"protocol=http\nhost=example.com\n\n",
+		"protocol=https\nhost=example.com:8443\n\n",
+	} {
+		if out := fill(input); strings.Contains(out, "s3cret") || strings.Contains(out, "pushing") {
+			t.Errorf("Expected no credential for %q, but received %q", input, out)
+		}
+	}
+	useHTTPPath := []string{"-c", "credential.useHttpPath=true"}
+	if out := fill("protocol=https\nhost=example.com\npath=team/repo.git\n\n", useHTTPPath...); !strings.Contains(out,
+		"password=s3cret") {
+		t.Fatalf("Expected the credential for team/repo.git, but received %q", out)
+	}
+	if out := fill("protocol=https\nhost=example.com\npath=other/repo.git\n\n", useHTTPPath...); strings.Contains(out,
+		"s3cret") {
+		t.Errorf("Expected no credential for other/repo.git, but received %q", out)
+	}
+}
+
+func TestNetworkExecutor(t *testing.T) {
+	setup()
+	requests := []CredentialRequest{}
+	provider := CredentialProviderFunc(func(request CredentialRequest) (Credential, error) {
+		requests = append(requests, request)
+		if request.Host == "denied.example.com" {
+			return Credential{}, errors.New("no credentials for " + request.Host)
+		}
+		return Credential{Username: "bob", Password: "s3cret"}, nil
+	})
+	controller := &realController{opts: ControllerOptions{Credentials: provider}}
+
+	// Remote names are resolved to their URL.
+	calls := [][]string{}
+	fake := createScriptedExecCommand(&calls, fakeResponse{stdout: "https://example.com/team/repo.git\n"})
+	url, err := getRemoteURL(fake, "origin")
+	if err != nil || url != "https://example.com/team/repo.git" {
+		t.Fatalf("Unexpected URL %s, %v", url, err)
+	}
+	expectedCmd := []string{"git", "ls-remote", "--get-url", "--", "origin"}
+	if !reflect.DeepEqual(calls[0], expectedCmd) {
+		t.Fatalf("Expected %v, but received %v", expectedCmd, calls[0])
+	}
+
+	// URLs are passed to the provider as they are.
+	if _, err := controller.networkExecutor("https://denied.example.com/repo.git", remoteFetch); err == nil {
+		t.Fatalf("Expected the provider's error")
+	}
+	executor, err := controller.networkExecutor("https://example.com/team/repo.git", remotePush)
+	if err != nil {
+		t.Fatalf("Expected nil error, but received %v", err)
+	}
+	if cmd := executor("git", "fetch"); len(cmd.Args) != 6 {
+		t.Fatalf("Expected the credential helper shim, but received %v", cmd.Args)
+	}
+	expectedRequests := []CredentialRequest{
+		{Protocol: "https", Host: "denied.example.com", Path: "repo.git"},
+		{Protocol: "https", Host: "example.com", Path: "team/repo.git"},
+	}
+	if !reflect.DeepEqual(requests, expectedRequests) {
+		t.Fatalf("Expected %+v, but received %+v", expectedRequests, requests)
+	}
+}
+
+func TestNetworkExecutorPushURL(t *testing.T) {
+	// Pushes authenticate to the remote's push URLs, which may be on other hosts than its fetch URL.
+	dir, err := ioutil.TempDir("", "gitoperations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"remote", "add", "origin", "https://example.com/team/repo.git"},
+		{"remote", "add", "mirror", "https://example.com/team/mirror.git"},
+		{"config", "url.https://push.example.com/.pushInsteadOf", "https://example.com/team/"},
+		{"config", "--add", "remote.origin.pushurl", "https://push.example.com/team/repo.git"},
+		{"config", "--add", "remote.origin.pushurl", "https://backup.example.net/repo.git"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	requests := []CredentialRequest{}
+	provider := CredentialProviderFunc(func(request CredentialRequest) (Credential, error) {
+		requests = append(requests, request)
+		return Credential{Username: "bob", Password: request.Host}, nil
+	})
+	controller := MakeControllerWithOptions(ControllerOptions{Dir: dir, Credentials: provider}).(*realController)
+	for _, test := range []struct {
+		remote   string
+		use      remoteUse
+		expected []CredentialRequest
+	}{
+		{"origin", remoteFetch, []CredentialRequest{{Protocol: "https", Host: "example.com", Path: "team/repo.git"}}},
+		{"origin", remotePush, []CredentialRequest{
+			{Protocol: "https", Host: "push.example.com", Path: "team/repo.git"},
+			{Protocol: "https", Host: "backup.example.net", Path: "repo.git"},
+		}},
+		{"origin", remoteFetch | remotePush, []CredentialRequest{
+			{Protocol: "https", Host: "example.com", Path: "team/repo.git"},
+			{Protocol: "https", Host: "push.example.com", Path: "team/repo.git"},
+			{Protocol: "https", Host: "backup.example.net", Path: "repo.git"},
+		}},
+		// Without a push URL the fetch URL is pushed to, after pushInsteadOf rewriting.
+		{"mirror", remotePush, []CredentialRequest{{Protocol: "https", Host: "push.example.com", Path: "mirror.git"}}},
+	} {
+		requests = requests[:0]
+		executor, err := controller.networkExecutor(test.remote, test.use)
+		if err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		if !reflect.DeepEqual(requests, test.expected) {
+			t.Errorf("Expected %+v for %s, but received %+v", test.expected, test.remote, requests)
+		}
+		if cmd := executor("git", "push"); len(cmd.Args) != 4+2*len(test.expected) {
+			t.Errorf("Expected a shim per request, but received %v", cmd.Args)
+		}
+		cmd := executor("git", "credential", "fill")
+		cmd.Env = append(cmd.Env, "GIT_TERMINAL_PROMPT=0", "GIT_ASKPASS=", "SSH_ASKPASS=")
+		last := test.expected[len(test.expected)-1]
+		cmd.Stdin = strings.NewReader("protocol=https\nhost=" + last.Host + "\n\n")
+		if out, _ := cmd.Output(); !strings.Contains(string(out), "password="+last.Host+"\n") {
+			t.Errorf("Expected the credential for %s, but received %q", last.Host, out)
+		}
+	}
+}
+
+func TestControllerEnvironment(t *testing.T) {
+	{
+		controller := &realController{opts: ControllerOptions{SSHKeyPath: "/keys/it's mine", NonInteractive: true}}
+		expected := []string{"GIT_TERMINAL_PROMPT=0", "GCM_INTERACTIVE=never", "GIT_ASKPASS=",
+			"SSH_ASKPASS_REQUIRE=never", "GPG_TTY=", "DISPLAY=", "WAYLAND_DISPLAY=",
+			`GIT_SSH_COMMAND=ssh -i '/keys/it'\''s mine' -o IdentitiesOnly=yes -o BatchMode=yes`}
+		if env := controller.environment(); !reflect.DeepEqual(env, expected) {
+			t.Fatalf("Expected %v, but received %v", expected, env)
+		}
+		cmd := controller.executor()("git", "status")
+		if !strings.Contains(strings.Join(cmd.Env, "\n"), "GIT_TERMINAL_PROMPT=0") {
+			t.Fatalf("Expected the executor to set the environment")
+		}
+	}
+	{
+		controller := &realController{opts: ControllerOptions{SSHKeyPath: "/keys/id", SSHCommand: "my-ssh"}}
+		expected := []string{"GIT_SSH_COMMAND=my-ssh"}
+		if env := controller.environment(); !reflect.DeepEqual(env, expected) {
+			t.Fatalf("Expected %v, but received %v", expected, env)
+		}
+	}
+	if env := new(realController).environment(); len(env) != 0 {
+		t.Fatalf("Expected no environment, but received %v", env)
+	}
+}
+
+func TestNonInteractiveAskPass(t *testing.T) {
+	// A configured askpass program must not be asked for credentials either.
+	dir, err := ioutil.TempDir("", "gitoperations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	askPass := filepath.Join(dir, "askpass")
+	if err := ioutil.WriteFile(askPass, []byte("#!/bin/sh\necho s3cret\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	fill := func(opts ControllerOptions) (string, error) {
+		cmd := MakeControllerWithOptions(opts).(*realController).executor()("git", "-c", "credential.helper=",
+			"-c", "core.askPass="+askPass, "credential", "fill")
+		cmd.Stdin = strings.NewReader("protocol=https\nhost=example.com\n\n")
+		out, err := cmd.Output()
+		return string(out), err
+	}
+	if out, _ := fill(ControllerOptions{}); !strings.Contains(out, "password=s3cret") {
+		t.Fatalf("Expected core.askPass to answer, but received %q", out)
+	}
+	if out, err := fill(ControllerOptions{NonInteractive: true}); err == nil || strings.Contains(out, "s3cret") {
+		t.Fatalf("Expected no credential, but received %q, %v", out, err)
+	}
+}
+
+func TestErrAuthenticationRequired(t *testing.T) {
+	setup()
+	for stderr, expected := range map[string]bool{
+		"fatal: could not read Username for 'https://example.com': terminal prompts disabled\n":            true,
+		"remote: Invalid username or password.\nfatal: Authentication failed for 'https://example.com/'\n": true,
+		"git@example.com: Permission denied (publickey).\n":                                                true,
+		"fatal: repository 'https://example.com/missing.git/' not found\n":                                 false,
+	} {
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{stderr: stderr, exitStatus: 128})
+		_, err := ListRemoteRefs(mockGit, "origin", nil, ListRemoteRefsOptions{})
+		if errors.Is(err, ErrAuthenticationRequired) != expected {
+			t.Errorf("Expected errors.Is(%v, ErrAuthenticationRequired) to be %v", err, expected)
+		}
+	}
+}
@@ -0,0 +1,364 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// TagMode selects how tags are fetched.
+type TagMode int
+
+const (
+	// TagsDefault follows git's default of fetching tags which point into the fetched history.
+	TagsDefault TagMode = iota
+	// TagsAll passes --tags.
+	TagsAll
+	// TagsNone passes --no-tags.
+	TagsNone
+)
+
+// FetchOptions controls FetchRemote.  The zero value fetches like a bare 'git fetch <remote>'.
+type FetchOptions struct {
+	Prune bool
+	Tags  TagMode
+	// Depth limits the fetched history to the given number of commits from each tip when greater than zero.
+	Depth int
+	// ShallowSince limits the fetched history to commits newer than the given date, in any format git understands.
+	ShallowSince string
+	// Filter requests a partial clone filter such as "blob:none" or "tree:0".
+	Filter string
+	// Atomic updates either all local refs or none of them.
+	Atomic bool
+	Force  bool
+	// Progress, when non-nil, receives progress as the fetch proceeds.
+	Progress ProgressFunc
+}
+
+// RefUpdateFlag classifies how a ref was updated by a fetch.
+type RefUpdateFlag int
+
+const (
+	RefFastForward RefUpdateFlag = iota
+	RefForced
+	RefNew
+	RefPruned
+	RefRejected
+	RefTagUpdate
+	RefUpToDate
+	// RefFetchHead is an object fetched into FETCH_HEAD alone, without updating a ref.
+	RefFetchHead
+)
+
+var refUpdateFlagNames = map[RefUpdateFlag]string{
+	RefFastForward: "fast-forward",
+	RefForced:      "forced",
+	RefNew:         "new",
+	RefPruned:      "pruned",
+	RefRejected:    "rejected",
+	RefTagUpdate:   "tag update",
+	RefUpToDate:    "up to date",
+	RefFetchHead:   "fetch head",
+}
+
+var refUpdateFlagsByCode = map[byte]RefUpdateFlag{
+	' ': RefFastForward,
+	'+': RefForced,
+	'*': RefNew,
+	'-': RefPruned,
+	'!': RefRejected,
+	't': RefTagUpdate,
+	'=': RefUpToDate,
+}
+
+func (flag RefUpdateFlag) String() string {
+	if name, ok := refUpdateFlagNames[flag]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// RefUpdate describes what a fetch did to a single local ref.
+// With git 2.41 or newer LocalRef is the full ref name and both object ids are complete.  Older versions only report
+// the abbreviated ref names git prints for humans, and additionally provide RemoteRef and Reason.  Their object ids are
+// complete too: git prints no ids for new, up to date and updated tag refs, whose new ids are looked up from LocalRef
+// after the fetch.  Older versions however leave empty the ids git does not know after the fetch, which are the old id
+// of a tag update and the ids of pruned and rejected refs and of RefFetchHead, as well as the new id of a LocalRef
+// which is ambiguous, such as "v1" naming both a branch and a tag.
+type RefUpdate struct {
+	Flag      RefUpdateFlag
+	OldOID    ObjectID
+	NewOID    ObjectID
+	LocalRef  string
+	RemoteRef string
+	Reason    string
+}
+
+// The minimum git version which supports 'git fetch --porcelain'.
+const fetchPorcelainMajor, fetchPorcelainMinor = 2, 41
+
+// FetchRemote fetches refspecs from remote, or the configured refspecs when refspecs is empty, and reports each ref
+// which was updated.  When git fails, for example because an update was rejected, the updates parsed so far are
+// returned together with the error, and with git older than 2.41 have no object ids.
+// An empty remote fetches from the current branch's default remote.
+func FetchRemote(exec Executor, remote string, refspecs []string, opts FetchOptions) ([]RefUpdate, error) {
+	if len(remote) == 0 && len(refspecs) != 0 {
+		return nil, errors.New("A remote is required when refspecs are given.")
+	}
+	version, err := GetGitVersion(exec)
+	if err != nil {
+		return nil, err
+	}
+	porcelain := version.AtLeast(fetchPorcelainMajor, fetchPorcelainMinor)
+
+	cmdArr := append([]string{"git", "fetch"}, fetchOptionArgs(opts)...)
+	if porcelain {
+		cmdArr = append(cmdArr, "--porcelain")
+	} else {
+		cmdArr = append(cmdArr, "--verbose")
+	}
+	if len(remote) != 0 {
+		cmdArr = append(append(cmdArr, "--", remote), refspecs...)
+	}
+	stdout, stderr, err := runAndReportProgress(exec, cmdArr, opts.Progress)
+	var updates []RefUpdate
+	var parseErr error
+	if porcelain {
+		updates, parseErr = parseFetchPorcelain(stdout)
+	} else {
+		var abbreviated [][2]string
+		updates, abbreviated, parseErr = parseFetchVerbose(stderr)
+		if err == nil && parseErr == nil {
+			parseErr = expandFetchObjectIDs(exec, updates, abbreviated)
+		}
+	}
+	if err != nil {
+		return updates, err
+	}
+	return updates, parseErr
+}
+
+func fetchOptionArgs(opts FetchOptions) []string {
+	args := []string{}
+	if opts.Prune {
+		args = append(args, "--prune")
+	}
+	switch opts.Tags {
+	case TagsAll:
+		args = append(args, "--tags")
+	case TagsNone:
+		args = append(args, "--no-tags")
+	}
+	if opts.Depth > 0 {
+		args = append(args, "--depth="+strconv.Itoa(opts.Depth))
+	}
+	if len(opts.ShallowSince) != 0 {
+		args = append(args, "--shallow-since="+opts.ShallowSince)
+	}
+	if len(opts.Filter) != 0 {
+		args = append(args, "--filter="+opts.Filter)
+	}
+	if opts.Atomic {
+		args = append(args, "--atomic")
+	}
+	if opts.Force {
+		args = append(args, "--force")
+	}
+	if opts.Progress != nil {
+		args = append(args, "--progress")
+	}
+	return args
+}
+
+func parseFetchPorcelain(output []byte) ([]RefUpdate, error) {
+	// Each line looks like:
+	// <flag> SP <old-object-id> SP <new-object-id> SP <local-reference>
+	updates := []RefUpdate{}
+	scanner := scanAndSplit(output)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(line) == 0 {
+			continue
+		}
+		flag, ok := refUpdateFlagsByCode[line[0]]
+		fields := strings.Fields(line[1:])
+		if !ok || len(fields) != 3 {
+			return updates, errors.New("Unrecognized fetch output: " + line)
+		}
+		oids, err := parseObjectIDs(fields[0], fields[1])
+		if err != nil {
+			return updates, err
+		}
+		if fields[2] == "FETCH_HEAD" {
+			flag = RefFetchHead
+		}
+		updates = append(updates, RefUpdate{Flag: flag, OldOID: oids[0], NewOID: oids[1], LocalRef: fields[2]})
+	}
+	return updates, nil
+}
+
+// expandFetchObjectIDs sets the ids of updates from the abbreviated old and new ids, which have the same indices, and
+// from the local refs of updates for which git prints no ids.
+func expandFetchObjectIDs(exec Executor, updates []RefUpdate, abbreviated [][2]string) error {
+	if err := expandAbbreviatedObjectIDs(exec, updates, abbreviated); err != nil {
+		return err
+	}
+	return lookUpFetchedRefs(exec, updates, abbreviated)
+}
+
+// expandAbbreviatedObjectIDs resolves the abbreviated ids in one rev-parse.  Git abbreviates ids so that they are
+// unambiguous, and has all of the objects after fetching.
+func expandAbbreviatedObjectIDs(exec Executor, updates []RefUpdate, abbreviated [][2]string) error {
+	cmdArr := []string{"git", "rev-parse"}
+	for _, pair := range abbreviated {
+		for _, abbrev := range pair {
+			if len(abbrev) != 0 {
+				cmdArr = append(cmdArr, abbrev)
+			}
+		}
+	}
+	if len(cmdArr) == 2 {
+		return nil
+	}
+	out, err := runAndGetOutput(exec, cmdArr)
+	if err != nil {
+		return err
+	}
+	full, err := parseObjectIDs(strings.Fields(string(out))...)
+	if err != nil {
+		return err
+	}
+	if len(full) != len(cmdArr)-2 {
+		return errors.New("Unrecognized rev-parse output: " + string(out))
+	}
+	for i, pair := range abbreviated {
+		if len(pair[0]) != 0 {
+			updates[i].OldOID, full = full[0], full[1:]
+		}
+		if len(pair[1]) != 0 {
+			updates[i].NewOID, full = full[0], full[1:]
+		}
+	}
+	return nil
+}
+
+// lookUpFetchedRefs sets the new ids of new, up to date and updated tag refs, for which git prints no ids, from their
+// local refs in one for-each-ref.  Git prints local refs with refs/heads/, refs/tags/ or refs/remotes/ removed, so
+// each is looked up under all three, and is left without ids when more than one exists.  As with --porcelain, the old
+// id of a new ref is the zero id, and that of an up to date ref is its new id.
+func lookUpFetchedRefs(exec Executor, updates []RefUpdate, abbreviated [][2]string) error {
+	// Each line looks like:
+	// <object-id> SP <ref>
+	indices := map[string][]int{}
+	cmdArr := []string{"git", "for-each-ref", "--format=%(objectname) %(refname)", "--"}
+	for i, update := range updates {
+		if len(abbreviated[i][1]) != 0 ||
+			update.Flag != RefNew && update.Flag != RefUpToDate && update.Flag != RefTagUpdate {
+			continue
+		}
+		refs := []string{update.LocalRef}
+		if !strings.HasPrefix(update.LocalRef, "refs/") {
+			refs = []string{"refs/heads/" + update.LocalRef, "refs/tags/" + update.LocalRef,
+				"refs/remotes/" + update.LocalRef}
+		}
+		for _, ref := range refs {
+			if _, ok := indices[ref]; !ok {
+				cmdArr = append(cmdArr, ref)
+			}
+			indices[ref] = append(indices[ref], i)
+		}
+	}
+	if len(indices) == 0 {
+		return nil
+	}
+	out, err := runAndGetOutput(exec, cmdArr)
+	if err != nil {
+		return err
+	}
+	found := make([]int, len(updates))
+	scanner := scanAndSplit(out)
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), " ", 2)
+		if len(fields) != 2 {
+			return errors.New("Unrecognized for-each-ref output: " + string(out))
+		}
+		// Patterns also match the refs below them, which are not wanted.
+		if _, ok := indices[fields[1]]; !ok {
+			continue
+		}
+		oid, err := ParseObjectID(fields[0])
+		if err != nil {
+			return err
+		}
+		for _, i := range indices[fields[1]] {
+			updates[i].NewOID = oid
+			found[i]++
+		}
+	}
+	for i := range updates {
+		switch {
+		case found[i] > 1:
+			updates[i].NewOID = ""
+		case found[i] == 1 && updates[i].Flag == RefNew:
+			updates[i].OldOID = ZeroObjectID(updates[i].NewOID.Format())
+		case found[i] == 1 && updates[i].Flag == RefUpToDate:
+			updates[i].OldOID = updates[i].NewOID
+		}
+	}
+	return nil
+}
+
+var reForFetchVerbose = regexp.MustCompile(`^ (.) (\[[^\]]*\]|\S+)\s+(\S+)\s+-> (\S+)(?:\s+\((.*)\))?$`)
+
+func parseFetchVerbose(output []byte) ([]RefUpdate, [][2]string, error) {
+	// Lines describing ref updates look like:
+	//    2539fe6..9b9ca25  main       -> origin/main
+	//  + 2539fe6...3fdc3a4 dev        -> origin/dev  (forced update)
+	//  * [new branch]      new        -> origin/new
+	//  - [deleted]         (none)     -> origin/gone
+	//  ! [rejected]        v1         -> v1  (would clobber existing tag)
+	//  t [tag update]      v2         -> v2
+	//  * branch            main       -> FETCH_HEAD
+	// The last is an object fetched into FETCH_HEAD alone, which is reported as RefFetchHead.
+	// Everything else written to stderr, such as the "From <url>" header and progress, is ignored.
+	// Progress output overwrites itself with carriage returns, so only the text after the last of them is considered.
+	// The abbreviated old and new ids of each update are returned separately, for expandFetchObjectIDs, which also
+	// looks up the new ids git does not print.
+	updates := []RefUpdate{}
+	abbreviated := [][2]string{}
+	scanner := scanAndSplit(output)
+	for scanner.Scan() {
+		line := scanner.Text()
+		line = line[strings.LastIndexByte(line, '\r')+1:]
+		matched := reForFetchVerbose.FindStringSubmatch(line)
+		if matched == nil {
+			continue
+		}
+		flag, ok := refUpdateFlagsByCode[matched[1][0]]
+		if !ok {
+			continue
+		}
+		if matched[4] == "FETCH_HEAD" {
+			flag = RefFetchHead
+		}
+		update := RefUpdate{Flag: flag, RemoteRef: matched[3], LocalRef: matched[4], Reason: matched[5]}
+		var oids [2]string
+		if update.RemoteRef == "(none)" {
+			update.RemoteRef = ""
+		}
+		if summary := matched[2]; strings.Contains(summary, "..") {
+			separator := ".."
+			if strings.Contains(summary, "...") {
+				separator = "..."
+			}
+			copy(oids[:], strings.SplitN(summary, separator, 2))
+		}
+		updates = append(updates, update)
+		abbreviated = append(abbreviated, oids)
+	}
+	return updates, abbreviated, nil
+}
@@ -0,0 +1,177 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFetchRemotePorcelain(t *testing.T) {
+	setup()
+	output := "  9b9ca25f6cbd9a4c9d4ad40ac3f9d0e6d4a8f0a1 3fdc3a4e2b5e6f8a9c0d1e2f3a4b5c6d7e8f9a0b refs/remotes/origin/main\n" +
+		"+ 2539fe6c0a1b2c3d4e5f60718293a4b5c6d7e8f9 3fdc3a4e2b5e6f8a9c0d1e2f3a4b5c6d7e8f9a0b refs/remotes/origin/dev\n" +
+		"* 0000000000000000000000000000000000000000 9b9ca25f6cbd9a4c9d4ad40ac3f9d0e6d4a8f0a1 refs/remotes/origin/new\n" +
+		"- 2539fe6c0a1b2c3d4e5f60718293a4b5c6d7e8f9 0000000000000000000000000000000000000000 refs/remotes/origin/gone\n" +
+		"* 0000000000000000000000000000000000000000 9b9ca25f6cbd9a4c9d4ad40ac3f9d0e6d4a8f0a1 FETCH_HEAD\n"
+	calls := [][]string{}
+	mockGit := createScriptedExecCommand(&calls,
+		fakeResponse{stdout: "git version 2.41.0\n"},
+		fakeResponse{stdout: output})
+	opts := FetchOptions{Prune: true, Tags: TagsNone, Depth: 5, ShallowSince: "2020-01-01", Filter: "blob:none",
+		Atomic: true}
+	updates, err := FetchRemote(mockGit, "origin", []string{"main", "+dev:refs/remotes/origin/dev"}, opts)
+	if err != nil {
+		t.Fatalf("Expected nil error, but received %v", err)
+	}
+	expectedCmd := []string{"git", "fetch", "--prune", "--no-tags", "--depth=5", "--shallow-since=2020-01-01",
+		"--filter=blob:none", "--atomic", "--porcelain", "--", "origin", "main", "+dev:refs/remotes/origin/dev"}
+	if !reflect.DeepEqual(calls[1], expectedCmd) {
+		t.Fatalf("Expected %v, but received %v", expectedCmd, calls[1])
+	}
+	expected := []RefUpdate{
+		{Flag: RefFastForward, OldOID: "9b9ca25f6cbd9a4c9d4ad40ac3f9d0e6d4a8f0a1",
+			NewOID: "3fdc3a4e2b5e6f8a9c0d1e2f3a4b5c6d7e8f9a0b", LocalRef: "refs/remotes/origin/main"},
+		{Flag: RefForced, OldOID: "2539fe6c0a1b2c3d4e5f60718293a4b5c6d7e8f9",
+			NewOID: "3fdc3a4e2b5e6f8a9c0d1e2f3a4b5c6d7e8f9a0b", LocalRef: "refs/remotes/origin/dev"},
+		{Flag: RefNew, OldOID: "0000000000000000000000000000000000000000",
+			NewOID: "9b9ca25f6cbd9a4c9d4ad40ac3f9d0e6d4a8f0a1", LocalRef: "refs/remotes/origin/new"},
+		{Flag: RefPruned, OldOID: "2539fe6c0a1b2c3d4e5f60718293a4b5c6d7e8f9",
+			NewOID: "0000000000000000000000000000000000000000", LocalRef: "refs/remotes/origin/gone"},
+		{Flag: RefFetchHead, OldOID: "0000000000000000000000000000000000000000",
+			NewOID: "9b9ca25f6cbd9a4c9d4ad40ac3f9d0e6d4a8f0a1", LocalRef: "FETCH_HEAD"},
+	}
+	if !reflect.DeepEqual(updates, expected) {
+		t.Fatalf("Expected %+v, but received %+v", expected, updates)
+	}
+}
+
+func TestFetchRemoteVerbose(t *testing.T) {
+	setup()
+	stderr := "From https://example.com/repo\n" +
+		" - [deleted]         (none)     -> origin/gone\n" +
+		" + 2539fe6...3fdc3a4 dev        -> origin/dev  (forced update)\n" +
+		"   2539fe6..9b9ca25  main       -> origin/main\n" +
+		" * [new branch]      new        -> origin/new\n" +
+		" = [up to date]      stable     -> origin/stable\n" +
+		" t [tag update]      v2         -> v2\n" +
+		" = [up to date]      both       -> both\n" +
+		" * branch            main       -> FETCH_HEAD\n"
+	calls := [][]string{}
+	mockGit := createScriptedExecCommand(&calls,
+		fakeResponse{stdout: "git version 2.39.3 (Apple Git-145)\n"},
+		fakeResponse{stderr: stderr},
+		fakeResponse{stdout: "2539fe6c0a1b2c3d4e5f60718293a4b5c6d7e8f9\n3fdc3a4e2b5e6f8a9c0d1e2f3a4b5c6d7e8f9a0b\n" +
+			"2539fe6c0a1b2c3d4e5f60718293a4b5c6d7e8f9\n9b9ca25f6cbd9a4c9d4ad40ac3f9d0e6d4a8f0a1\n"},
+		fakeResponse{stdout: "" +
+			"3fdc3a4e2b5e6f8a9c0d1e2f3a4b5c6d7e8f9a0b refs/heads/both\n" +
+			"5f1e0d2c3b4a59687766554433221100ffeeddcc refs/remotes/origin/new\n" +
+			"2539fe6c0a1b2c3d4e5f60718293a4b5c6d7e8f9 refs/remotes/origin/new/topic\n" +
+			"9b9ca25f6cbd9a4c9d4ad40ac3f9d0e6d4a8f0a1 refs/remotes/origin/stable\n" +
+			"3fdc3a4e2b5e6f8a9c0d1e2f3a4b5c6d7e8f9a0b refs/tags/both\n" +
+			"0a1b2c3d4e5f60718293a4b5c6d7e8f901234567 refs/tags/v2\n"})
+	updates, err := FetchRemote(mockGit, "", nil, FetchOptions{Tags: TagsAll})
+	if err != nil {
+		t.Fatalf("Expected nil error, but received %v", err)
+	}
+	// Git abbreviates ids, which are then expanded, and omits the ids of new, up to date and updated tag refs, which
+	// are looked up under each name git may have shortened the local ref from.
+	expectedCmds := [][]string{
+		{"git", "version"},
+		{"git", "fetch", "--tags", "--verbose"},
+		{"git", "rev-parse", "2539fe6", "3fdc3a4", "2539fe6", "9b9ca25"},
+		{"git", "for-each-ref", "--format=%(objectname) %(refname)", "--",
+			"refs/heads/origin/new", "refs/tags/origin/new", "refs/remotes/origin/new",
+			"refs/heads/origin/stable", "refs/tags/origin/stable", "refs/remotes/origin/stable",
+			"refs/heads/v2", "refs/tags/v2", "refs/remotes/v2",
+			"refs/heads/both", "refs/tags/both", "refs/remotes/both"},
+	}
+	if !reflect.DeepEqual(calls, expectedCmds) {
+		t.Fatalf("Expected %v, but received %v", expectedCmds, calls)
+	}
+	// Git does not print the old id of a tag update, nor that of an ambiguous ref.
+	expected := []RefUpdate{
+		{Flag: RefPruned, LocalRef: "origin/gone"},
+		{Flag: RefForced, OldOID: "2539fe6c0a1b2c3d4e5f60718293a4b5c6d7e8f9",
+			NewOID: "3fdc3a4e2b5e6f8a9c0d1e2f3a4b5c6d7e8f9a0b", RemoteRef: "dev", LocalRef: "origin/dev",
+			Reason: "forced update"},
+		{Flag: RefFastForward, OldOID: "2539fe6c0a1b2c3d4e5f60718293a4b5c6d7e8f9",
+			NewOID: "9b9ca25f6cbd9a4c9d4ad40ac3f9d0e6d4a8f0a1", RemoteRef: "main", LocalRef: "origin/main"},
+		{Flag: RefNew, OldOID: "0000000000000000000000000000000000000000",
+			NewOID: "5f1e0d2c3b4a59687766554433221100ffeeddcc", RemoteRef: "new", LocalRef: "origin/new"},
+		{Flag: RefUpToDate, OldOID: "9b9ca25f6cbd9a4c9d4ad40ac3f9d0e6d4a8f0a1",
+			NewOID: "9b9ca25f6cbd9a4c9d4ad40ac3f9d0e6d4a8f0a1", RemoteRef: "stable", LocalRef: "origin/stable"},
+		{Flag: RefTagUpdate, NewOID: "0a1b2c3d4e5f60718293a4b5c6d7e8f901234567", RemoteRef: "v2", LocalRef: "v2"},
+		{Flag: RefUpToDate, RemoteRef: "both", LocalRef: "both"},
+		{Flag: RefFetchHead, RemoteRef: "main", LocalRef: "FETCH_HEAD"},
+	}
+	if !reflect.DeepEqual(updates, expected) {
+		t.Fatalf("Expected %+v, but received %+v", expected, updates)
+	}
+}
+
+func TestFetchRemoteVerboseFailure(t *testing.T) {
+	setup()
+	// The updates are returned without looking up their ids, which could hide the fetch's error.
+	stderr := "   2539fe6..9b9ca25  main       -> origin/main\n" +
+		" ! [rejected]        v1         -> v1  (would clobber existing tag)\n"
+	calls := [][]string{}
+	mockGit := createScriptedExecCommand(&calls,
+		fakeResponse{stdout: "git version 2.39.3 (Apple Git-145)\n"},
+		fakeResponse{stderr: stderr, exitStatus: 1})
+	updates, err := FetchRemote(mockGit, "origin", nil, FetchOptions{})
+	if exitCodeOf(err) != 1 {
+		t.Fatalf("Expected exit status 1, but received %v", err)
+	}
+	if len(calls) != 2 {
+		t.Fatalf("Expected no commands after the fetch, but received %v", calls[2:])
+	}
+	expected := []RefUpdate{
+		{Flag: RefFastForward, RemoteRef: "main", LocalRef: "origin/main"},
+		{Flag: RefRejected, RemoteRef: "v1", LocalRef: "v1", Reason: "would clobber existing tag"},
+	}
+	if !reflect.DeepEqual(updates, expected) {
+		t.Fatalf("Expected %+v, but received %+v", expected, updates)
+	}
+}
+
+func TestFetchRemoteErrors(t *testing.T) {
+	setup()
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{})
+		if _, err := FetchRemote(mockGit, "", []string{"main"}, FetchOptions{}); err == nil {
+			t.Fatalf("Expected non-nil error")
+		}
+		if len(calls) != 0 {
+			t.Fatalf("Expected no git invocation, but received %v", calls)
+		}
+	}
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{stdout: "not git\n"})
+		if _, err := FetchRemote(mockGit, "origin", nil, FetchOptions{}); err == nil {
+			t.Fatalf("Expected non-nil error")
+		}
+	}
+}
+
+func TestGetGitVersion(t *testing.T) {
+	setup()
+	for output, expected := range map[string]GitVersion{
+		"git version 2.39.3 (Apple Git-145)\n": {2, 39, 3},
+		"git version 2.45.1.windows.1\n":       {2, 45, 1},
+		"git version 3.0\n":                    {3, 0, 0},
+	} {
+		calls := [][]string{}
+		version, err := GetGitVersion(createScriptedExecCommand(&calls, fakeResponse{stdout: output}))
+		if err != nil || version != expected {
+			t.Errorf("Expected %v, but received %v, %v", expected, version, err)
+		}
+	}
+	if !(GitVersion{2, 41, 0}).AtLeast(2, 41) || (GitVersion{2, 40, 9}).AtLeast(2, 41) ||
+		!(GitVersion{3, 0, 0}).AtLeast(2, 41) {
+		t.Errorf("AtLeast compared versions incorrectly")
+	}
+}
@@ -10,13 +10,16 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"os/exec"
 	"path"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type LoggingInfo struct {
@@ -25,6 +28,8 @@ type LoggingInfo struct {
 	trace          bool
 	tracePrefix    string
 	traceFn        func(format string, v ...interface{})
+	// redactions are the patterns registered with AddRedactionPattern.
+	redactions []*regexp.Regexp
 }
 
 type Controller interface {
@@ -37,30 +42,266 @@ type Controller interface {
 	// Deprecated: Use GetUpstreamForRef
 	GetTrackingBranch() (string, error)
 	HasUncommittedChanges() bool
+	// Deprecated: use instead: AheadBehind
 	RefIsAheadBehind(ref string) (ahead int, behind int, err error)
 	// Deprecated: use instead: RefIsAheadBehind
 	BranchIsAheadOfOrigin(branch string) (bool, string, error)
 	IsInsideAGitWorkingTree() (bool, error)
 	GetTopLevel() (string, error)
+	// Deprecated: use instead: ParentCommit
 	GetParentCommit() (string, error)
+	// Deprecated: use instead: HeadCommit
 	GetHeadCommit() (string, error)
 	CountCommitsWithGtOneParent(currentBranch string, ancestorCommit string) (int, error)
+	// Deprecated: use instead: MergeBase
 	GetMergeBase(parentCommit string, targetBranch string) (string, error)
 	GetGraphToHead(currentBranch string, mergeTarget string, numLines int) (string, error)
+	// Deprecated: use instead: LastCommitOnBranch
 	GetLastCommitOnBranch(branch string) (string, error)
+	// ResolveCommit returns the id of the commit a revision names.
+	ResolveCommit(revision string) (ObjectID, error)
+	HeadCommit() (ObjectID, error)
+	ParentCommit() (ObjectID, error)
+	LastCommitOnBranch(branch string) (ObjectID, error)
+	// MergeBase returns the common ancestor git would choose for merging revision and other.
+	MergeBase(revision string, other string) (ObjectID, error)
+	GetObjectFormat() (ObjectFormat, error)
 	GetGlobalConfigSetting(setting string) (string, error)
 	GetConfigSetting(setting string) (string, error)
 	GitCanExecute() error
+	// ValidateBranchName and ValidateRefName check untrusted names with 'git check-ref-format'.
+	ValidateBranchName(branch string) error
+	ValidateRefName(ref string) error
+	ListConflicts() ([]Conflict, error)
+	ReadConflictVersion(path string, side ConflictSide) ([]byte, error)
+	ResolveConflict(path string, resolution ConflictResolution) error
+	MarkResolved(paths []string) error
+	ListRemotes() ([]Remote, error)
+	AddRemote(name string, url string) error
+	RemoveRemote(name string) error
+	SetRemoteURL(name string, url string, push bool) error
+	RenameRemote(oldName string, newName string) error
+	SetRemoteHead(name string) error
+	GetGitVersion() (GitVersion, error)
+	Fetch(remote string, refspecs []string, opts FetchOptions) ([]RefUpdate, error)
+	Push(remote string, refspecs []string, opts PushOptions) ([]PushResult, error)
+	ListRemoteRefs(remoteOrURL string, patterns []string, opts ListRemoteRefsOptions) ([]RemoteRef, error)
+	// Clone and Init return a Controller which runs git in the new repository.
+	Clone(url string, dir string, opts CloneOptions) (Controller, error)
+	Init(dir string, opts InitOptions) (Controller, error)
+	ListWorktrees() ([]Worktree, error)
+	// AddWorktree returns a Controller which runs git in the new worktree.
+	AddWorktree(path string, opts AddWorktreeOptions) (Controller, error)
+	RemoveWorktree(path string, force bool) error
+	MoveWorktree(path string, newPath string) error
+	LockWorktree(path string, reason string) error
+	UnlockWorktree(path string) error
+	PruneWorktrees(expire string) error
+	// StashPush returns the commit of the new stash.
+	StashPush(opts StashPushOptions) (ObjectID, error)
+	ListStashes() ([]StashEntry, error)
+	StashApply(ref string, restoreIndex bool) ([]Conflict, error)
+	StashPop(ref string, restoreIndex bool) ([]Conflict, error)
+	StashDrop(ref string) error
+	StashShow(ref string) ([]FileChange, error)
+	ConfigList(scope ConfigScope) ([]ConfigEntry, error)
+	// DiscoverRepository describes the repository containing path, which is relative to the controller's directory.
+	DiscoverRepository(path string) (RepositoryInfo, error)
+	ConfigGet(scope ConfigScope, key string) (string, error)
+	ConfigGetOrDefault(scope ConfigScope, key string, defaultValue string) (string, error)
+	ConfigGetAll(scope ConfigScope, key string) ([]string, error)
+	ConfigGetRegexp(scope ConfigScope, pattern string) ([]ConfigEntry, error)
+	ConfigGetURLMatch(name string, url string) ([]ConfigEntry, error)
+	ConfigGetBool(scope ConfigScope, key string) (bool, error)
+	ConfigGetInt(scope ConfigScope, key string) (int64, error)
+	ConfigGetPath(scope ConfigScope, key string) (string, error)
+	ConfigGetExpiryDate(scope ConfigScope, key string) (time.Time, error)
+	ConfigSet(scope ConfigScope, key string, value string) error
+	ConfigAdd(scope ConfigScope, key string, value string) error
+	ConfigUnset(scope ConfigScope, key string, all bool) error
+	ListSubmodules() ([]Submodule, error)
+	// SubmoduleUpdate and SubmoduleSync take paths relative to the controller's directory.  Submodules are fetched
+	// with the credentials of the user's credential helpers.
+	SubmoduleUpdate(paths []string, opts SubmoduleUpdateOptions) error
+	SubmoduleSync(paths []string, recursive bool) error
+	// ForEachSubmodule calls fn for each initialized submodule, in index order, with a Controller which runs git in the
+	// submodule.  It stops at, and returns, the first error fn returns.
+	ForEachSubmodule(fn func(submodule Submodule, controller Controller) error) error
+	// VerifyCommit, VerifyRange and VerifyTag check signatures with the keys gpg knows and, for SSH signatures, the
+	// allowed signers file configured with gpg.ssh.allowedSignersFile.
+	VerifyCommit(revision string) (CommitSignature, error)
+	VerifyRange(base string, head string) ([]CommitSignature, error)
+	VerifyTag(tag string) error
+	// CreateCommit, CommitTree, Merge and CreateTag sign as the user's configuration says unless their signing options
+	// say otherwise.
+	CreateCommit(opts CommitOptions) (ObjectID, error)
+	CommitTree(tree string, parents []string, message string, signing SigningOptions) (ObjectID, error)
+	Merge(revisions []string, opts MergeOptions) ([]Conflict, error)
+	CreateTag(name string, target string, opts TagOptions) error
+	// Blame blames path, relative to the controller's directory, as of revision or of the working tree when revision
+	// is empty.
+	Blame(path string, revision string, opts BlameOptions) ([]BlameLine, error)
+	Grep(pattern string, opts GrepOptions) ([]GrepMatch, error)
+	// GrepStream passes matches to fn as they are found, and stops at the first error fn returns.
+	GrepStream(pattern string, opts GrepOptions, fn GrepFunc) error
+	// IsAncestor reports whether ancestor is reachable from descendant, treating a commit as its own ancestor.
+	IsAncestor(ancestor string, descendant string) (bool, error)
+	MergeBases(revisions []string, octopus bool) ([]ObjectID, error)
+	ForkPoint(ref string, upstream string) (ObjectID, error)
+	// BranchesContaining and TagsContaining return full ref names.
+	BranchesContaining(commit string, remotes bool) ([]string, error)
+	TagsContaining(commit string) ([]string, error)
+	// AheadBehind compares refs with base, or with their upstreams when base is empty.
+	AheadBehind(base string, refs ...string) ([]AheadBehindCount, error)
+	// CheckHistoryPolicy returns every violation of policy by the commits reachable from head but not from base.
+	CheckHistoryPolicy(base string, head string, policy HistoryPolicy) ([]PolicyViolation, error)
+	// RunSquashMergeWorkflow squashes a branch into a target branch and pushes it, rolling back on failure.
+	RunSquashMergeWorkflow(workflow SquashMergeWorkflow) (ObjectID, error)
 }
 
-type realController struct{}
+// ControllerOptions configures the Controller returned by MakeControllerWithOptions.
+type ControllerOptions struct {
+	// Dir is the directory git runs in.  When empty git runs in the current working directory.
+	Dir string
+	// Credentials, when non-nil, supplies the credentials for HTTP(S) remotes in place of the user's credential
+	// helpers.
+	Credentials CredentialProvider
+	// SSHKeyPath selects the private key used to authenticate to SSH remotes.
+	SSHKeyPath string
+	// SSHCommand replaces the ssh command git runs, like GIT_SSH_COMMAND.  It takes precedence over SSHKeyPath.
+	SSHCommand string
+	// NonInteractive guarantees git never waits for a password or passphrase to be typed.  Operations which need
+	// credentials that were not supplied fail with an error matching ErrAuthenticationRequired instead, and those which
+	// need the passphrase of a signing key with an error matching ErrSigningFailed.
+	NonInteractive bool
+}
+
+type realController struct {
+	opts ControllerOptions
+}
 
 func MakeController() Controller {
 	return new(realController)
 }
 
+// MakeControllerForDir returns a Controller which runs git in dir instead of the current working directory.
+func MakeControllerForDir(dir string) Controller {
+	return MakeControllerWithOptions(ControllerOptions{Dir: dir})
+}
+
+func MakeControllerWithOptions(opts ControllerOptions) Controller {
+	return &realController{opts: opts}
+}
+
+// executor returns the Executor which runs commands on behalf of the controller.
+func (Controller *realController) executor() Executor {
+	dir := Controller.opts.Dir
+	env := Controller.environment()
+	if len(dir) == 0 && len(env) == 0 {
+		return exec.Command
+	}
+	return func(name string, args ...string) *exec.Cmd {
+		cmd := exec.Command(name, args...)
+		cmd.Dir = dir
+		if len(env) != 0 {
+			cmd.Env = append(os.Environ(), env...)
+		}
+		return cmd
+	}
+}
+
+// environment returns the variables, beyond those of the current process, which commands run by the controller need.
+func (Controller *realController) environment() []string {
+	env := []string{}
+	if Controller.opts.NonInteractive {
+		env = append(env, "GIT_TERMINAL_PROMPT=0", "GCM_INTERACTIVE=never")
+		// An empty GIT_ASKPASS makes git skip core.askPass and SSH_ASKPASS as well, and ssh honours
+		// SSH_ASKPASS_REQUIRE.
+		env = append(env, "GIT_ASKPASS=", "SSH_ASKPASS_REQUIRE=never")
+		// Without a terminal or display gpg's pinentry fails at once rather than waiting for a passphrase.
+		env = append(env, "GPG_TTY=", "DISPLAY=", "WAYLAND_DISPLAY=")
+	}
+	sshCommand := Controller.opts.SSHCommand
+	if len(sshCommand) == 0 && (len(Controller.opts.SSHKeyPath) != 0 || Controller.opts.NonInteractive) {
+		sshCommand = "ssh"
+		if len(Controller.opts.SSHKeyPath) != 0 {
+			sshCommand += " -i " + shellQuote(Controller.opts.SSHKeyPath) + " -o IdentitiesOnly=yes"
+		}
+		if Controller.opts.NonInteractive {
+			sshCommand += " -o BatchMode=yes"
+		}
+	}
+	if len(sshCommand) != 0 {
+		env = append(env, "GIT_SSH_COMMAND="+sshCommand)
+	}
+	return env
+}
+
+// remoteUse says whether an operation fetches from a remote, pushes to it, or both.
+type remoteUse int
+
+const (
+	remoteFetch remoteUse = 1 << iota
+	remotePush
+)
+
+// networkExecutor returns the Executor for commands which contact remoteOrURL.  When the controller has a
+// CredentialProvider it is consulted for the remote's URLs, looked up first when given a remote name, and its answers
+// are supplied to git.  A remote's fetch URL is looked up for remoteFetch, and its push URLs, which may be on other
+// hosts, for remotePush.
+func (Controller *realController) networkExecutor(remoteOrURL string, use remoteUse) (Executor, error) {
+	executor := Controller.executor()
+	if Controller.opts.Credentials == nil {
+		return executor, nil
+	}
+	urls := []string{remoteOrURL}
+	if _, ok := makeCredentialRequest(remoteOrURL); !ok {
+		urls = nil
+		if use&remoteFetch != 0 {
+			url, err := getRemoteURL(executor, remoteOrURL)
+			if err != nil {
+				return nil, err
+			}
+			urls = append(urls, url)
+		}
+		if use&remotePush != 0 {
+			pushURLs, err := getRemotePushURLs(executor, remoteOrURL)
+			if err != nil {
+				return nil, err
+			}
+			urls = append(urls, pushURLs...)
+		}
+	}
+	credentials := []remoteCredential{}
+	requested := map[CredentialRequest]bool{}
+	for _, url := range urls {
+		request, ok := makeCredentialRequest(url)
+		if !ok || requested[request] {
+			continue
+		}
+		requested[request] = true
+		credential, err := Controller.opts.Credentials.GetCredential(request)
+		if err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, remoteCredential{request: request, credential: credential})
+	}
+	return withCredentials(executor, credentials), nil
+}
+
+// forDir returns a controller configured like this one which runs git in dir, interpreted relative to the
+// controller's directory.
+func (Controller *realController) forDir(dir string) Controller {
+	opts := Controller.opts
+	if !filepath.IsAbs(dir) && len(opts.Dir) != 0 {
+		dir = filepath.Join(opts.Dir, dir)
+	}
+	opts.Dir = dir
+	return MakeControllerWithOptions(opts)
+}
+
 func (Controller *realController) RunSuppliedExecutableWithArgs(commandandargs []string) error {
-	return RunSuppliedExecutableWithArgs(exec.Command, commandandargs)
+	return RunSuppliedExecutableWithArgs(Controller.executor(), commandandargs)
 }
 
 func (Controller *realController) WhichGit() (string, error) {
@@ -68,76 +309,423 @@ func (Controller *realController) WhichGit() (string, error) {
 }
 
 func (Controller *realController) GetTopLevel() (string, error) {
-	return GetTopLevel(exec.Command)
+	return GetTopLevel(Controller.executor())
 }
 
 func (Controller *realController) IsInsideAGitWorkingTree() (bool, error) {
-	return IsInsideAGitWorkingTree(exec.Command)
+	return IsInsideAGitWorkingTree(Controller.executor())
 }
 
 func (Controller *realController) GetBranch() (string, error) {
-	return GetBranch(exec.Command)
+	return GetBranch(Controller.executor())
 }
 
 func (Controller *realController) GetRefForHead() (string, error) {
-	return GetRefForHead(exec.Command)
+	return GetRefForHead(Controller.executor())
 }
 
 func (Controller *realController) GetHeadCommit() (string, error) {
-	return GetHeadCommit(exec.Command)
+	return GetHeadCommit(Controller.executor())
 }
 
 func (Controller *realController) GetMergeBase(parentCommit string, targetBranch string) (string, error) {
-	return GetMergeBase(exec.Command, parentCommit, targetBranch)
+	return GetMergeBase(Controller.executor(), parentCommit, targetBranch)
 }
 
 func (Controller *realController) GetParentCommit() (string, error) {
-	return GetParentCommit(exec.Command)
+	return GetParentCommit(Controller.executor())
 }
 
 // Deprecated: Use GetUpstreamForRef instead.
 func (Controller *realController) GetTrackingBranch() (string, error) {
-	return GetTrackingBranch(exec.Command)
+	return GetTrackingBranch(Controller.executor())
 }
 
 func (Controller *realController) HasUncommittedChanges() bool {
-	return HasUncommittedChanges(exec.Command)
+	return HasUncommittedChanges(Controller.executor())
 }
 
 func (Controller *realController) RefIsAheadBehind(ref string) (int, int, error) {
-	return RefIsAheadBehind(exec.Command, ref)
+	return RefIsAheadBehind(Controller.executor(), ref)
 }
 
 func (Controller *realController) BranchIsAheadOfOrigin(branch string) (bool, string, error) {
-	return BranchIsAheadOfOrigin(exec.Command, branch)
+	return BranchIsAheadOfOrigin(Controller.executor(), branch)
 }
 
 func (Controller *realController) GetUpstreamForRef(ref string) (string, error) {
-	return GetUpstreamForRef(exec.Command, ref)
+	return GetUpstreamForRef(Controller.executor(), ref)
+}
+
+func (Controller *realController) ResolveCommit(revision string) (ObjectID, error) {
+	return ResolveCommit(Controller.executor(), revision)
+}
+
+func (Controller *realController) HeadCommit() (ObjectID, error) {
+	return HeadCommit(Controller.executor())
+}
+
+func (Controller *realController) ParentCommit() (ObjectID, error) {
+	return ParentCommit(Controller.executor())
+}
+
+func (Controller *realController) LastCommitOnBranch(branch string) (ObjectID, error) {
+	return LastCommitOnBranch(Controller.executor(), branch)
+}
+
+func (Controller *realController) MergeBase(revision string, other string) (ObjectID, error) {
+	return MergeBase(Controller.executor(), revision, other)
+}
+
+func (Controller *realController) GetObjectFormat() (ObjectFormat, error) {
+	return GetObjectFormat(Controller.executor())
 }
 
 func (Controller *realController) GetGlobalConfigSetting(setting string) (string, error) {
-	return GetGlobalConfigSetting(exec.Command, setting)
+	return GetGlobalConfigSetting(Controller.executor(), setting)
 }
 
 func (Controller *realController) GetConfigSetting(setting string) (string, error) {
-	return GetConfigSetting(exec.Command, setting)
+	return GetConfigSetting(Controller.executor(), setting)
 }
 
 func (Controller *realController) GitCanExecute() error {
-	return GitCanExecute(exec.Command)
+	return GitCanExecute(Controller.executor())
 }
 
 func (Controller *realController) GetLastCommitOnBranch(branch string) (string, error) {
-	return GetLastCommitOnBranch(exec.Command, branch)
+	return GetLastCommitOnBranch(Controller.executor(), branch)
 }
 
 func (Controller *realController) CountCommitsWithGtOneParent(currentBranch string, ancestorCommit string) (int, error) {
-	return CountCommitsWithGtOneParent(exec.Command, currentBranch, ancestorCommit)
+	return CountCommitsWithGtOneParent(Controller.executor(), currentBranch, ancestorCommit)
 }
 
 func (Controller *realController) GetGraphToHead(currentBranch string, mergeTarget string, numLines int) (string, error) {
-	return GetGraphToHead(exec.Command, currentBranch, mergeTarget, numLines)
+	return GetGraphToHead(Controller.executor(), currentBranch, mergeTarget, numLines)
+}
+
+func (Controller *realController) ValidateBranchName(branch string) error {
+	return ValidateBranchName(Controller.executor(), branch)
+}
+
+func (Controller *realController) ValidateRefName(ref string) error {
+	return ValidateRefName(Controller.executor(), ref)
+}
+
+func (Controller *realController) ListConflicts() ([]Conflict, error) {
+	return ListConflicts(Controller.executor())
+}
+
+func (Controller *realController) ReadConflictVersion(path string, side ConflictSide) ([]byte, error) {
+	return ReadConflictVersion(Controller.executor(), path, side)
+}
+
+func (Controller *realController) ResolveConflict(path string, resolution ConflictResolution) error {
+	return ResolveConflict(Controller.executor(), path, resolution)
+}
+
+func (Controller *realController) MarkResolved(paths []string) error {
+	return MarkResolved(Controller.executor(), paths)
+}
+
+func (Controller *realController) ListRemotes() ([]Remote, error) {
+	return ListRemotes(Controller.executor())
+}
+
+func (Controller *realController) AddRemote(name string, url string) error {
+	return AddRemote(Controller.executor(), name, url)
+}
+
+func (Controller *realController) RemoveRemote(name string) error {
+	return RemoveRemote(Controller.executor(), name)
+}
+
+func (Controller *realController) SetRemoteURL(name string, url string, push bool) error {
+	return SetRemoteURL(Controller.executor(), name, url, push)
+}
+
+func (Controller *realController) RenameRemote(oldName string, newName string) error {
+	return RenameRemote(Controller.executor(), oldName, newName)
+}
+
+func (Controller *realController) SetRemoteHead(name string) error {
+	executor, err := Controller.networkExecutor(name, remoteFetch)
+	if err != nil {
+		return err
+	}
+	return SetRemoteHead(executor, name)
+}
+
+func (Controller *realController) GetGitVersion() (GitVersion, error) {
+	return GetGitVersion(Controller.executor())
+}
+
+func (Controller *realController) Fetch(remote string, refspecs []string, opts FetchOptions) ([]RefUpdate, error) {
+	executor, err := Controller.networkExecutor(remote, remoteFetch)
+	if err != nil {
+		return nil, err
+	}
+	return FetchRemote(executor, remote, refspecs, opts)
+}
+
+func (Controller *realController) Push(remote string, refspecs []string, opts PushOptions) ([]PushResult, error) {
+	executor, err := Controller.networkExecutor(remote, remotePush)
+	if err != nil {
+		return nil, err
+	}
+	return PushRemote(executor, remote, refspecs, opts)
+}
+
+func (Controller *realController) ListRemoteRefs(remoteOrURL string, patterns []string,
+	opts ListRemoteRefsOptions) ([]RemoteRef, error) {
+	executor, err := Controller.networkExecutor(remoteOrURL, remoteFetch)
+	if err != nil {
+		return nil, err
+	}
+	return ListRemoteRefs(executor, remoteOrURL, patterns, opts)
+}
+
+func (Controller *realController) Clone(url string, dir string, opts CloneOptions) (Controller, error) {
+	executor, err := Controller.networkExecutor(url, remoteFetch)
+	if err != nil {
+		return nil, err
+	}
+	if err := Clone(executor, url, dir, opts); err != nil {
+		return nil, err
+	}
+	return Controller.forDir(dir), nil
+}
+
+func (Controller *realController) Init(dir string, opts InitOptions) (Controller, error) {
+	if err := Init(Controller.executor(), dir, opts); err != nil {
+		return nil, err
+	}
+	return Controller.forDir(dir), nil
+}
+
+func (Controller *realController) ListWorktrees() ([]Worktree, error) {
+	return ListWorktrees(Controller.executor())
+}
+
+func (Controller *realController) AddWorktree(path string, opts AddWorktreeOptions) (Controller, error) {
+	if err := AddWorktree(Controller.executor(), path, opts); err != nil {
+		return nil, err
+	}
+	return Controller.forDir(path), nil
+}
+
+func (Controller *realController) RemoveWorktree(path string, force bool) error {
+	return RemoveWorktree(Controller.executor(), path, force)
+}
+
+func (Controller *realController) MoveWorktree(path string, newPath string) error {
+	return MoveWorktree(Controller.executor(), path, newPath)
+}
+
+func (Controller *realController) LockWorktree(path string, reason string) error {
+	return LockWorktree(Controller.executor(), path, reason)
+}
+
+func (Controller *realController) UnlockWorktree(path string) error {
+	return UnlockWorktree(Controller.executor(), path)
+}
+
+func (Controller *realController) PruneWorktrees(expire string) error {
+	return PruneWorktrees(Controller.executor(), expire)
+}
+
+func (Controller *realController) StashPush(opts StashPushOptions) (ObjectID, error) {
+	return StashPush(Controller.executor(), opts)
+}
+
+func (Controller *realController) ListStashes() ([]StashEntry, error) {
+	return ListStashes(Controller.executor())
+}
+
+func (Controller *realController) StashApply(ref string, restoreIndex bool) ([]Conflict, error) {
+	return StashApply(Controller.executor(), ref, restoreIndex)
+}
+
+func (Controller *realController) StashPop(ref string, restoreIndex bool) ([]Conflict, error) {
+	return StashPop(Controller.executor(), ref, restoreIndex)
+}
+
+func (Controller *realController) StashDrop(ref string) error {
+	return StashDrop(Controller.executor(), ref)
+}
+
+func (Controller *realController) StashShow(ref string) ([]FileChange, error) {
+	return StashShow(Controller.executor(), ref)
+}
+
+func (Controller *realController) DiscoverRepository(path string) (RepositoryInfo, error) {
+	if !filepath.IsAbs(path) && len(Controller.opts.Dir) != 0 {
+		path = filepath.Join(Controller.opts.Dir, path)
+	}
+	return DiscoverRepository(Controller.executor(), path)
+}
+
+func (Controller *realController) ConfigList(scope ConfigScope) ([]ConfigEntry, error) {
+	return ConfigList(Controller.executor(), scope)
+}
+
+func (Controller *realController) ConfigGet(scope ConfigScope, key string) (string, error) {
+	return ConfigGet(Controller.executor(), scope, key)
+}
+
+func (Controller *realController) ConfigGetOrDefault(scope ConfigScope, key string, defaultValue string) (string,
+	error) {
+	return ConfigGetOrDefault(Controller.executor(), scope, key, defaultValue)
+}
+
+func (Controller *realController) ConfigGetAll(scope ConfigScope, key string) ([]string, error) {
+	return ConfigGetAll(Controller.executor(), scope, key)
+}
+
+func (Controller *realController) ConfigGetRegexp(scope ConfigScope, pattern string) ([]ConfigEntry, error) {
+	return ConfigGetRegexp(Controller.executor(), scope, pattern)
+}
+
+func (Controller *realController) ConfigGetURLMatch(name string, url string) ([]ConfigEntry, error) {
+	return ConfigGetURLMatch(Controller.executor(), name, url)
+}
+
+func (Controller *realController) ConfigGetBool(scope ConfigScope, key string) (bool, error) {
+	return ConfigGetBool(Controller.executor(), scope, key)
+}
+
+func (Controller *realController) ConfigGetInt(scope ConfigScope, key string) (int64, error) {
+	return ConfigGetInt(Controller.executor(), scope, key)
+}
+
+func (Controller *realController) ConfigGetPath(scope ConfigScope, key string) (string, error) {
+	return ConfigGetPath(Controller.executor(), scope, key)
+}
+
+func (Controller *realController) ConfigGetExpiryDate(scope ConfigScope, key string) (time.Time, error) {
+	return ConfigGetExpiryDate(Controller.executor(), scope, key)
+}
+
+func (Controller *realController) ConfigSet(scope ConfigScope, key string, value string) error {
+	return ConfigSet(Controller.executor(), scope, key, value)
+}
+
+func (Controller *realController) ConfigAdd(scope ConfigScope, key string, value string) error {
+	return ConfigAdd(Controller.executor(), scope, key, value)
+}
+
+func (Controller *realController) ConfigUnset(scope ConfigScope, key string, all bool) error {
+	return ConfigUnset(Controller.executor(), scope, key, all)
+}
+
+func (Controller *realController) ListSubmodules() ([]Submodule, error) {
+	return ListSubmodules(Controller.executor())
+}
+
+func (Controller *realController) SubmoduleUpdate(paths []string, opts SubmoduleUpdateOptions) error {
+	return SubmoduleUpdate(Controller.executor(), paths, opts)
+}
+
+func (Controller *realController) SubmoduleSync(paths []string, recursive bool) error {
+	return SubmoduleSync(Controller.executor(), paths, recursive)
+}
+
+func (Controller *realController) ForEachSubmodule(fn func(submodule Submodule, controller Controller) error) error {
+	topLevel, submodules, err := listSubmodules(Controller.executor())
+	if err != nil {
+		return err
+	}
+	for _, submodule := range submodules {
+		if !submodule.Initialized() {
+			continue
+		}
+		if err := fn(submodule, Controller.forDir(filepath.Join(topLevel, submodule.Path))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (Controller *realController) VerifyCommit(revision string) (CommitSignature, error) {
+	return VerifyCommit(Controller.executor(), revision)
+}
+
+func (Controller *realController) VerifyRange(base string, head string) ([]CommitSignature, error) {
+	return VerifyRange(Controller.executor(), base, head)
+}
+
+func (Controller *realController) VerifyTag(tag string) error {
+	return VerifyTag(Controller.executor(), tag)
+}
+
+func (Controller *realController) CreateCommit(opts CommitOptions) (ObjectID, error) {
+	return CreateCommit(Controller.executor(), opts)
+}
+
+func (Controller *realController) CommitTree(tree string, parents []string, message string,
+	signing SigningOptions) (ObjectID, error) {
+	return CommitTree(Controller.executor(), tree, parents, message, signing)
+}
+
+func (Controller *realController) Merge(revisions []string, opts MergeOptions) ([]Conflict, error) {
+	return Merge(Controller.executor(), revisions, opts)
+}
+
+func (Controller *realController) CreateTag(name string, target string, opts TagOptions) error {
+	return CreateTag(Controller.executor(), name, target, opts)
+}
+
+func (Controller *realController) Blame(path string, revision string, opts BlameOptions) ([]BlameLine, error) {
+	return Blame(Controller.executor(), path, revision, opts)
+}
+
+func (Controller *realController) Grep(pattern string, opts GrepOptions) ([]GrepMatch, error) {
+	return Grep(Controller.executor(), pattern, opts)
+}
+
+func (Controller *realController) GrepStream(pattern string, opts GrepOptions, fn GrepFunc) error {
+	return GrepStream(Controller.executor(), pattern, opts, fn)
+}
+
+func (Controller *realController) IsAncestor(ancestor string, descendant string) (bool, error) {
+	return IsAncestor(Controller.executor(), ancestor, descendant)
+}
+
+func (Controller *realController) MergeBases(revisions []string, octopus bool) ([]ObjectID, error) {
+	return MergeBases(Controller.executor(), revisions, octopus)
+}
+
+func (Controller *realController) ForkPoint(ref string, upstream string) (ObjectID, error) {
+	return ForkPoint(Controller.executor(), ref, upstream)
+}
+
+func (Controller *realController) BranchesContaining(commit string, remotes bool) ([]string, error) {
+	return BranchesContaining(Controller.executor(), commit, remotes)
+}
+
+func (Controller *realController) TagsContaining(commit string) ([]string, error) {
+	return TagsContaining(Controller.executor(), commit)
+}
+
+func (Controller *realController) AheadBehind(base string, refs ...string) ([]AheadBehindCount, error) {
+	return AheadBehind(Controller.executor(), base, refs...)
+}
+
+func (Controller *realController) CheckHistoryPolicy(base string, head string,
+	policy HistoryPolicy) ([]PolicyViolation, error) {
+	return CheckHistoryPolicy(Controller.executor(), base, head, policy)
+}
+
+func (Controller *realController) RunSquashMergeWorkflow(workflow SquashMergeWorkflow) (ObjectID, error) {
+	if len(workflow.Remote) == 0 {
+		workflow.Remote = "origin"
+	}
+	executor, err := Controller.networkExecutor(workflow.Remote, remoteFetch|remotePush)
+	if err != nil {
+		return "", err
+	}
+	return RunSquashMergeWorkflow(executor, workflow)
 }
 
 var (
@@ -155,7 +743,7 @@ func GetTrace() bool {
 
 func maybeTrace(cmds []string) {
 	if loggingInfo.trace {
-		loggingInfo.traceFn(loggingInfo.tracePrefix+"%s\n", strings.Join(cmds, " "))
+		loggingInfo.traceFn(loggingInfo.tracePrefix+"%s\n", Redact(strings.Join(cmds, " ")))
 	}
 }
 
@@ -183,6 +771,157 @@ func runAndGetCombinedOutput(exec Executor, cmdArr []string) (output []byte, err
 	return
 }
 
+// GitError describes a git invocation which exited unsuccessfully.  Stderr holds whatever git wrote to its standard
+// error stream so callers can inspect the reason for the failure.  Secrets are redacted from both Args and Stderr.
+type GitError struct {
+	Args     []string
+	ExitCode int
+	Stderr   string
+	Err      error
+}
+
+func (e *GitError) Error() string {
+	msg := fmt.Sprintf("%s: %v", strings.Join(e.Args, " "), e.Err)
+	if stderr := strings.TrimSpace(e.Stderr); len(stderr) != 0 {
+		msg += ": " + stderr
+	}
+	return msg
+}
+
+func (e *GitError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the failure matches target, which allows errors.Is to recognize ErrAuthenticationRequired.
+func (e *GitError) Is(target error) bool {
+	return target == ErrAuthenticationRequired && isAuthenticationFailure(e.Stderr)
+}
+
+// runAndGetOutput runs the command and returns its standard output alone, which keeps warnings written to stderr from
+// corrupting machine readable output.  When the command fails the error is a *GitError.
+func runAndGetOutput(exec Executor, cmdArr []string) ([]byte, error) {
+	return runWithInputAndGetOutput(exec, cmdArr, nil)
+}
+
+// runWithInputAndGetOutput behaves like runAndGetOutput, additionally feeding input to the command's standard input.
+func runWithInputAndGetOutput(exec Executor, cmdArr []string, input []byte) ([]byte, error) {
+	out, _, err := runAndGetSeparateOutputs(exec, cmdArr, input)
+	return out, err
+}
+
+// runAndGetSeparateOutputs runs the command, feeding it input when non-nil, and returns what it wrote to stdout and
+// stderr.  When the command fails the error is a *GitError.
+func runAndGetSeparateOutputs(exec Executor, cmdArr []string, input []byte) (stdout []byte, stderr []byte, err error) {
+	return runCommand(exec, cmdArr, input, nil)
+}
+
+// runAndReportProgress behaves like runAndGetSeparateOutputs, additionally passing the progress git reports on stderr
+// to progress when it is non-nil.  The command should be given --progress, since git only reports progress to a
+// terminal otherwise.
+func runAndReportProgress(exec Executor, cmdArr []string, progress ProgressFunc) (stdout []byte, stderr []byte,
+	err error) {
+	if progress == nil {
+		return runCommand(exec, cmdArr, nil, nil)
+	}
+	writer := &progressWriter{progress: progress}
+	stdout, stderr, err = runCommand(exec, cmdArr, nil, writer)
+	writer.Flush()
+	return
+}
+
+// runCommand runs the command, copying stderr to stderrSink as it is written when stderrSink is non-nil.
+func runCommand(exec Executor, cmdArr []string, input []byte, stderrSink io.Writer) (stdout []byte, stderr []byte,
+	err error) {
+	maybeTrace(cmdArr)
+	cmd := exec(cmdArr[0], cmdArr[1:]...)
+	var stdoutBuf, stderrBuf bytes.Buffer
+	cmd.Stdout = &stdoutBuf
+	cmd.Stderr = &stderrBuf
+	if stderrSink != nil {
+		cmd.Stderr = io.MultiWriter(&stderrBuf, stderrSink)
+	}
+	if input != nil {
+		cmd.Stdin = bytes.NewReader(input)
+	}
+	err = cmd.Run()
+	stdout, stderr = stdoutBuf.Bytes(), stderrBuf.Bytes()
+	if err != nil {
+		err = newGitError(cmdArr, string(stderr), err)
+	}
+	return
+}
+
+// runAndStreamOutput runs the command, passing each line of its standard output to fn, without the trailing newline,
+// as git writes it.  When fn returns an error the command is killed and the error returned.  When the command fails
+// the error is a *GitError.
+func runAndStreamOutput(exec Executor, cmdArr []string, fn func(line []byte) error) error {
+	maybeTrace(cmdArr)
+	cmd := exec(cmdArr[0], cmdArr[1:]...)
+	var stderrBuf bytes.Buffer
+	cmd.Stderr = &stderrBuf
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return newGitError(cmdArr, "", err)
+	}
+	reader := bufio.NewReader(stdout)
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if len(line) != 0 {
+			if err := fn(bytes.TrimSuffix(line, []byte("\n"))); err != nil {
+				_ = cmd.Process.Kill()
+				_ = cmd.Wait()
+				return err
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			_ = cmd.Process.Kill()
+			_ = cmd.Wait()
+			return readErr
+		}
+	}
+	if err := cmd.Wait(); err != nil {
+		return newGitError(cmdArr, stderrBuf.String(), err)
+	}
+	return nil
+}
+
+func newGitError(cmdArr []string, stderr string, err error) *GitError {
+	gitErr := &GitError{Args: redactAll(cmdArr), ExitCode: -1, Stderr: Redact(stderr), Err: err}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		gitErr.ExitCode = exitErr.ExitCode()
+	}
+	return gitErr
+}
+
+// exitCodeOf returns the exit status carried by err, or -1 when err does not describe a process which exited.
+func exitCodeOf(err error) int {
+	var gitErr *GitError
+	if errors.As(err, &gitErr) {
+		return gitErr.ExitCode
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// splitNul splits output produced by a git command run with -z into its NUL terminated records.
+func splitNul(output []byte) []string {
+	records := strings.Split(string(output), "\x00")
+	if len(records) > 0 && records[len(records)-1] == "" {
+		records = records[:len(records)-1]
+	}
+	return records
+}
+
 func scanAndSplit(output []byte) *bufio.Scanner {
 	scanner := bufio.NewScanner(bytes.NewReader(output))
 	scanner.Split(bufio.ScanLines)
@@ -223,7 +962,10 @@ func GetRefForHead(exec Executor) (string, error) {
 }
 
 func GetUpstreamForRef(exec Executor, ref string) (string, error) {
-	cmdArr := []string{"git", "for-each-ref", "--format=%(upstream:short)", ref}
+	if err := checkArguments(ref); err != nil {
+		return "", err
+	}
+	cmdArr := []string{"git", "for-each-ref", "--format=%(upstream:short)", "--", ref}
 	out, err := runAndGetCombinedOutput(exec, cmdArr)
 	if err != nil {
 		return strings.TrimSpace(string(out)), fmt.Errorf("Unable to identify upstream for %s: %v", ref, err)
@@ -289,6 +1031,7 @@ func HasUncommittedChanges(exec Executor) bool {
 	return false
 }
 
+// Deprecated: Use AheadBehind instead, which also reports when the upstream is gone rather than counting 0 and 0.
 func RefIsAheadBehind(exec Executor, ref string) (ahead int, behind int, err error) {
 	// Example ref argument refs/heads/mainline
 	// Pre: ref has a tracking branch
@@ -297,7 +1040,10 @@ func RefIsAheadBehind(exec Executor, ref string) (ahead int, behind int, err err
 
 	// example strings to parse:
 	//[ahead 1, behind 1]
-	cmdArr := []string{"git", "for-each-ref", "--format=\"%(upstream:track)\"", ref}
+	if err = checkArguments(ref); err != nil {
+		return
+	}
+	cmdArr := []string{"git", "for-each-ref", "--format=\"%(upstream:track)\"", "--", ref}
 	out, err := runAndGetCombinedOutput(exec, cmdArr)
 	if err != nil {
 		return
@@ -341,16 +1087,17 @@ func BranchIsAheadOfOrigin(exec Executor, branch string) (bool, string, error) {
 	if err != nil {
 		return false, proof, err
 	}
-	ReForBranchLocation, err := regexp.Compile(`^\*?\s+` + branch + `\s+.*`)
+	quotedBranch := regexp.QuoteMeta(branch)
+	ReForBranchLocation, err := regexp.Compile(`^\*?\s+` + quotedBranch + `\s+.*`)
 	if err != nil {
 		return false, "", err
 	}
-	ReForHasUpstream, err := regexp.Compile(`^\*?\s+` + branch + `\s+(\S+)\s+\[(.*)`)
+	ReForHasUpstream, err := regexp.Compile(`^\*?\s+` + quotedBranch + `\s+(\S+)\s+\[(.*)`)
 	if err != nil {
 		return false, "", err
 	}
 
-	ReForAheadUpstream, err := regexp.Compile(`^\*?\s+` + branch + `\s+(\S+)\s+\[[^\]]+: ahead\s+([^\]]+)\]\s+.*`)
+	ReForAheadUpstream, err := regexp.Compile(`^\*?\s+` + quotedBranch + `\s+(\S+)\s+\[[^\]]+: ahead\s+([^\]]+)\]\s+.*`)
 	if err != nil {
 		return false, "", err
 	}
@@ -368,12 +1115,15 @@ func BranchIsAheadOfOrigin(exec Executor, branch string) (bool, string, error) {
 		}
 		return false, "", nil
 	}
-	return false, "", errors.New("Unable to locate branch in git output.\nGit output:\n" + string(out))
+	return false, "", errors.New("Unable to locate branch in git output.\nGit output:\n" + Redact(string(out)))
 }
 
 // Deprecated: Checkout functionality should be access via RunSppliedExecutableWithArgs
 func Checkout(exec Executor, currentBranch string, targetBranch string) error {
-	cmdArr := []string{"git", "checkout", targetBranch}
+	if err := checkArguments(targetBranch); err != nil {
+		return err
+	}
+	cmdArr := []string{"git", "checkout", targetBranch, "--"}
 	maybeTrace(cmdArr)
 	if err := RunLoudly(exec(cmdArr[0], cmdArr[1:]...)); err != nil {
 		return errors.New("Failed to checkout " + targetBranch + ". Repository will be left in " +
@@ -384,7 +1134,10 @@ func Checkout(exec Executor, currentBranch string, targetBranch string) error {
 
 // Deprecated: Fetch functionality should be accessed via RunSuppliedExecutableWithArgs
 func Fetch(exec Executor, branch string) error {
-	cmdArr := []string{"git", "fetch", "-p", "origin", fmt.Sprintf("%s:%s", branch, branch)}
+	if err := checkArguments(branch); err != nil {
+		return err
+	}
+	cmdArr := []string{"git", "fetch", "-p", "--", "origin", fmt.Sprintf("%s:%s", branch, branch)}
 	maybeTrace(cmdArr)
 	cmd := exec(cmdArr[0], cmdArr[1:]...)
 	return RunLoudly(cmd)
@@ -392,11 +1145,14 @@ func Fetch(exec Executor, branch string) error {
 
 // Deprecated: Pull functionality should be accessed via RunSuppliedExecutableWithArgs
 func Pull(exec Executor, srcBranch string, rebase bool) error {
-	rebaseStr := ""
+	if err := checkArguments(srcBranch); err != nil {
+		return err
+	}
+	cmdArr := []string{"git", "pull"}
 	if rebase {
-		rebaseStr = "--rebase"
+		cmdArr = append(cmdArr, "--rebase")
 	}
-	cmdArr := []string{"git", "pull", rebaseStr, ".", srcBranch}
+	cmdArr = append(cmdArr, "--", ".", srcBranch)
 	maybeTrace(cmdArr)
 	cmd := exec(cmdArr[0], cmdArr[1:]...)
 	return RunLoudly(cmd)
@@ -404,8 +1160,11 @@ func Pull(exec Executor, srcBranch string, rebase bool) error {
 
 // Deprecated: ResetTarget functionality should be accessed via RunSuppliedExecutableWithArgs
 func ResetTarget(exec Executor, targetBranch string) error {
+	if err := checkArguments(targetBranch); err != nil {
+		return err
+	}
 	cmdArr := []string{"git", "reset", "--hard",
-		fmt.Sprintf("origin/%s", targetBranch)}
+		fmt.Sprintf("origin/%s", targetBranch), "--"}
 	maybeTrace(cmdArr)
 	cmd := exec(cmdArr[0], cmdArr[1:]...)
 	return RunLoudly(cmd)
@@ -413,7 +1172,10 @@ func ResetTarget(exec Executor, targetBranch string) error {
 
 // Deprecated: DeleteBranch functionality should be accessed via RunSuppliedExecutableWithArgs
 func DeleteBranch(exec Executor, sourceBranch string) error {
-	cmdArr := []string{"git", "branch", "-D", sourceBranch}
+	if err := checkArguments(sourceBranch); err != nil {
+		return err
+	}
+	cmdArr := []string{"git", "branch", "-D", "--", sourceBranch}
 	maybeTrace(cmdArr)
 	cmd := exec(cmdArr[0], cmdArr[1:]...)
 	return RunLoudly(cmd)
@@ -421,7 +1183,10 @@ func DeleteBranch(exec Executor, sourceBranch string) error {
 
 // Deprecated: MergeSourceToTarget functionality should be accessed via RunSuppliedExecutableWithArgs
 func MergeSourceToTarget(exec Executor, sourceBranch string) error {
-	cmdArr := []string{"git", "merge", "--squash", sourceBranch}
+	if err := checkArguments(sourceBranch); err != nil {
+		return err
+	}
+	cmdArr := []string{"git", "merge", "--squash", "--end-of-options", sourceBranch}
 	maybeTrace(cmdArr)
 	cmd := exec(cmdArr[0], cmdArr[1:]...)
 	return RunLoudly(cmd)
@@ -436,7 +1201,7 @@ func Commit(exec Executor) error {
 
 // Deprecated: Push functionality should be accessed via RunSuppliedExecutableWithArgs
 func Push(exec Executor) error {
-	cmdArr := append([]string{"git", "push"})
+	cmdArr := []string{"git", "push"}
 	maybeTrace(cmdArr)
 	cmd := exec(cmdArr[0], cmdArr[1:]...)
 	return RunLoudly(cmd)
@@ -447,7 +1212,7 @@ func IsInsideAGitWorkingTree(exec Executor) (bool, error) {
 	cmdArr := []string{"git", "rev-parse", "--is-inside-work-tree"}
 	out, err := runAndGetCombinedOutput(exec, cmdArr)
 	if err != nil {
-		return false, errors.New(string(out))
+		return false, errors.New(Redact(string(out)))
 	}
 
 	scanner := scanAndSplit(out)
@@ -473,7 +1238,7 @@ func GetTopLevel(exec Executor) (string, error) {
 	cmdArr := []string{"git", "rev-parse", "--show-toplevel"}
 	out, err := runAndGetCombinedOutput(exec, cmdArr)
 	if err != nil {
-		return "", errors.New(string(out))
+		return "", errors.New(Redact(string(out)))
 	}
 
 	scanner := scanAndSplit(out)
@@ -484,6 +1249,7 @@ func GetTopLevel(exec Executor) (string, error) {
 	return strings.TrimRight(scanner.Text(), "\n"), nil
 }
 
+// Deprecated: Use ParentCommit instead, which returns an ObjectID.
 func GetParentCommit(exec Executor) (string, error) {
 	// Returns the parent (HEAD~) commit hash.
 	// Error is non-nil when the command fails.
@@ -502,6 +1268,7 @@ func GetParentCommit(exec Executor) (string, error) {
 	return line, nil
 }
 
+// Deprecated: Use HeadCommit instead, which returns an ObjectID.
 func GetHeadCommit(exec Executor) (string, error) {
 	// Returns the HEAD commit hash.
 	// Error is non-nil when the command fails.
@@ -526,7 +1293,11 @@ func CountCommitsWithGtOneParent(exec Executor, currentBranch string, ancestorCo
 	// Error is non-nil when the command fails.
 	// Having greater than one parent indicates that the last commit is not maintaining linear history, and for some
 	// users that is a property to keep track of.
-	cmdArr := []string{"git", "rev-list", "--count", "--min-parents=2", fmt.Sprintf("--branches=%s", currentBranch), "--ancestry-path", ancestorCommit + "..HEAD"}
+	if err := checkArguments(ancestorCommit); err != nil {
+		return 0, err
+	}
+	cmdArr := []string{"git", "rev-list", "--count", "--min-parents=2", fmt.Sprintf("--branches=%s", currentBranch), "--ancestry-path",
+		"--end-of-options", ancestorCommit + "..HEAD", "--"}
 	out, err := runAndGetCombinedOutput(exec, cmdArr)
 	if err != nil {
 		return 0, errors.New("Parent Count Check: " + err.Error())
@@ -541,6 +1312,8 @@ func CountCommitsWithGtOneParent(exec Executor, currentBranch string, ancestorCo
 	return count, err
 }
 
+// Deprecated: Use MergeBase instead, which returns an ObjectID and git's errors as GitErrors, or MergeBases to find
+// every merge base.
 func GetMergeBase(exec Executor, parentCommit string, targetBranch string) (string, error) {
 	// Identify the common ancestor which will be used in the event of a merge.
 	// parentCommit: Should be the sole parent of HEAD.  User is responsible for ensuring HEAD has only a single
@@ -548,7 +1321,10 @@ func GetMergeBase(exec Executor, parentCommit string, targetBranch string) (stri
 	// targetBranch: The branch we would possibly merge into.  Could be: origin/mainline
 	// Returns: hash of the merge base, non-nil error when an error occurs.
 
-	cmdArray := []string{"git", "merge-base", targetBranch, parentCommit}
+	if err := checkArguments(targetBranch, parentCommit); err != nil {
+		return "", err
+	}
+	cmdArray := []string{"git", "merge-base", "--end-of-options", targetBranch, parentCommit}
 	maybeTrace(cmdArray)
 	cmd := exec(cmdArray[0], cmdArray[1:]...)
 	out, err := cmd.CombinedOutput()
@@ -567,8 +1343,11 @@ func GetGraphToHead(exec Executor, currentBranch string, mergeTarget string, num
 	var sb strings.Builder
 	// mergeTarget will be ommitted from the output by the command below.  If mergeTarget~ is used instead, we find that extranous
 	// descendencts of mergebase get output.
+	if err := checkArguments(mergeTarget); err != nil {
+		return "", err
+	}
 	cmdArr := []string{"git", "log", "--decorate", "--oneline", "--graph", "--all", fmt.Sprintf("--branches=%s", currentBranch), "--ancestry-path",
-		mergeTarget + "..HEAD"}
+		"--end-of-options", mergeTarget + "..HEAD", "--"}
 	out, err := runAndGetCombinedOutput(exec, cmdArr)
 	if err != nil {
 		return string(out), err
@@ -586,9 +1365,13 @@ func GetGraphToHead(exec Executor, currentBranch string, mergeTarget string, num
 	return sb.String(), nil
 }
 
+// Deprecated: Use LastCommitOnBranch instead, which returns an ObjectID.
 func GetLastCommitOnBranch(exec Executor, branch string) (string, error) {
 	// Returns the last commit in given branch.
-	cmdArr := []string{"git", "log", branch, "-n1", "--format=format:%H"}
+	if err := checkArguments(branch); err != nil {
+		return "", err
+	}
+	cmdArr := []string{"git", "log", "-n1", "--format=format:%H", "--end-of-options", branch, "--"}
 	out, err := runAndGetCombinedOutput(exec, cmdArr)
 	if err != nil {
 		return string(out), err
@@ -602,10 +1385,12 @@ func GetLastCommitOnBranch(exec Executor, branch string) (string, error) {
 }
 
 func GetGlobalConfigSetting(exec Executor, setting string) (string, error) {
-	cmdArr := []string{"git", "config", "--global", "--get", setting}
-	out, err := runAndGetCombinedOutput(exec, cmdArr)
+	// A setting which is not set results in an error matching ErrConfigKeyNotFound; ConfigGetOrDefault supplies a
+	// default instead.
+	cmdArr := []string{"git", "config", "--global", "--get", "--", setting}
+	out, err := runAndGetOutput(exec, cmdArr)
 	if err != nil {
-		return string(out), err
+		return "", configError(setting, err)
 	}
 	scanner := bufio.NewScanner(bytes.NewReader(out))
 	if !scanner.Scan() {
@@ -616,10 +1401,12 @@ func GetGlobalConfigSetting(exec Executor, setting string) (string, error) {
 }
 
 func GetConfigSetting(exec Executor, setting string) (string, error) {
-	cmdArr := []string{"git", "config", "--get", setting}
-	out, err := runAndGetCombinedOutput(exec, cmdArr)
+	// A setting which is not set results in an error matching ErrConfigKeyNotFound; ConfigGetOrDefault supplies a
+	// default instead.
+	cmdArr := []string{"git", "config", "--get", "--", setting}
+	out, err := runAndGetOutput(exec, cmdArr)
 	if err != nil {
-		return string(out), err
+		return "", configError(setting, err)
 	}
 	scanner := bufio.NewScanner(bytes.NewReader(out))
 	if !scanner.Scan() {
@@ -629,6 +1416,40 @@ func GetConfigSetting(exec Executor, setting string) (string, error) {
 	return strings.TrimSpace(string(line)), nil
 }
 
+// GitVersion is the version of the git executable, as reported by 'git version'.
+type GitVersion struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// AtLeast reports whether the version is major.minor or newer.
+func (version GitVersion) AtLeast(major int, minor int) bool {
+	return version.Major > major || (version.Major == major && version.Minor >= minor)
+}
+
+func (version GitVersion) String() string {
+	return fmt.Sprintf("%d.%d.%d", version.Major, version.Minor, version.Patch)
+}
+
+func GetGitVersion(exec Executor) (GitVersion, error) {
+	// Parses output such as "git version 2.39.3 (Apple Git-145)" or "git version 2.45.1.windows.1".
+	cmdArr := []string{"git", "version"}
+	out, err := runAndGetOutput(exec, cmdArr)
+	if err != nil {
+		return GitVersion{}, err
+	}
+	matched := regexp.MustCompile(`^git version (\d+)\.(\d+)(?:\.(\d+))?`).FindStringSubmatch(string(out))
+	if matched == nil {
+		return GitVersion{}, errors.New("Unrecognized git version: " + strings.TrimSpace(string(out)))
+	}
+	version := GitVersion{}
+	version.Major, _ = strconv.Atoi(matched[1])
+	version.Minor, _ = strconv.Atoi(matched[2])
+	version.Patch, _ = strconv.Atoi(matched[3])
+	return version, nil
+}
+
 func GitCanExecute(exec Executor) error {
 	// Simple test to make sure we can get git to execute.
 	// Returns non-nil error if git can not execute a simple command.
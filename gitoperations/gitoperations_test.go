@@ -4,6 +4,8 @@
 package gitoperations
 
 import (
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -31,7 +33,16 @@ func TestExecCommandHelper(t *testing.T) {
 	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
 		return
 	}
-	fmt.Fprintf(os.Stdout, os.Getenv("STDOUT"))
+	if encoded, ok := os.LookupEnv("STDOUT_HEX"); ok {
+		decoded, _ := hex.DecodeString(encoded)
+		os.Stdout.Write(decoded)
+	} else {
+		fmt.Fprintf(os.Stdout, os.Getenv("STDOUT"))
+	}
+	if encoded, ok := os.LookupEnv("STDERR_HEX"); ok {
+		decoded, _ := hex.DecodeString(encoded)
+		os.Stderr.Write(decoded)
+	}
 	i, _ := strconv.Atoi(os.Getenv("EXIT_STATUS"))
 	os.Exit(i)
 }
@@ -52,6 +63,34 @@ func createFakeExecCommand(stdErrorOut string, exitStatus int) Executor {
 	}
 }
 
+// fakeResponse describes how a single mocked git invocation behaves.
+type fakeResponse struct {
+	stdout     string
+	stderr     string
+	exitStatus int
+}
+
+// Like createFakeExecCommand, but replies to successive invocations with the given responses in order (repeating the
+// last one once they run out) and records the command line of every invocation in calls.  Output is passed to the
+// helper process hex encoded so it may contain NUL bytes.
+func createScriptedExecCommand(calls *[][]string, responses ...fakeResponse) Executor {
+	return func(command string, args ...string) *exec.Cmd {
+		invocation := append([]string{command}, args...)
+		response := responses[len(responses)-1]
+		if len(*calls) < len(responses) {
+			response = responses[len(*calls)]
+		}
+		*calls = append(*calls, invocation)
+		cs := append([]string{"-test.run=TestExecCommandHelper", "--"}, invocation...)
+		cmd := exec.Command(os.Args[0], cs...)
+		cmd.Env = []string{"GO_WANT_HELPER_PROCESS=1",
+			"STDOUT_HEX=" + hex.EncodeToString([]byte(response.stdout)),
+			"STDERR_HEX=" + hex.EncodeToString([]byte(response.stderr)),
+			"EXIT_STATUS=" + strconv.Itoa(response.exitStatus)}
+		return cmd
+	}
+}
+
 func TestCheckout(t *testing.T) {
 	{
 		setup()
@@ -870,15 +909,26 @@ func TestGetGlobalConfigSetting(t *testing.T) {
 			t.Errorf("Expected '%s', but received '%s'", expected, message)
 		}
 	}
-	{ // Failure
-		expected := "bar\n"
-		mockFailure := createFakeExecCommand(expected, 1)
+	{ // Setting not set
+		mockFailure := createFakeExecCommand("", 1)
 		message, err := GetGlobalConfigSetting(mockFailure, "pull.rebase")
-		if err == nil {
-			t.Errorf("Expected non-nil error")
+		if !errors.Is(err, ErrConfigKeyNotFound) {
+			t.Errorf("Expected ErrConfigKeyNotFound, but received %v", err)
 		}
-		if message != expected {
-			t.Errorf("Expected '%s' but received '%s'", expected, message)
+		if message != "" {
+			t.Errorf("Expected empty value but received '%s'", message)
+		}
+	}
+	{ // Invalid setting
+		calls := [][]string{}
+		mockFailure := createScriptedExecCommand(&calls,
+			fakeResponse{stderr: "error: key does not contain a section: pull\n", exitStatus: 1})
+		message, err := GetGlobalConfigSetting(mockFailure, "pull")
+		if !errors.Is(err, ErrInvalidConfigKey) {
+			t.Errorf("Expected ErrInvalidConfigKey, but received %v", err)
+		}
+		if message != "" {
+			t.Errorf("Expected empty value but received '%s'", message)
 		}
 	}
 	{ // No setting found
@@ -911,15 +961,26 @@ func TestGetConfigSetting(t *testing.T) {
 			t.Errorf("Expected '%s', but received '%s'", expected, message)
 		}
 	}
-	{ // Failure
-		expected := "bar\n"
-		mockFailure := createFakeExecCommand(expected, 1)
+	{ // Setting not set
+		mockFailure := createFakeExecCommand("", 1)
 		message, err := GetConfigSetting(mockFailure, "pull.rebase")
-		if err == nil {
-			t.Errorf("Expected non-nil error")
+		if !errors.Is(err, ErrConfigKeyNotFound) {
+			t.Errorf("Expected ErrConfigKeyNotFound, but received %v", err)
 		}
-		if message != expected {
-			t.Errorf("Expected '%s' but received '%s'", expected, message)
+		if message != "" {
+			t.Errorf("Expected empty value but received '%s'", message)
+		}
+	}
+	{ // Invalid setting
+		calls := [][]string{}
+		mockFailure := createScriptedExecCommand(&calls,
+			fakeResponse{stderr: "error: key does not contain a section: pull\n", exitStatus: 1})
+		message, err := GetConfigSetting(mockFailure, "pull")
+		if !errors.Is(err, ErrInvalidConfigKey) {
+			t.Errorf("Expected ErrInvalidConfigKey, but received %v", err)
+		}
+		if message != "" {
+			t.Errorf("Expected empty value but received '%s'", message)
 		}
 	}
 	{ // No setting found
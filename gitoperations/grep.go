@@ -0,0 +1,139 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// GrepPatternType selects how Grep interprets its pattern.
+type GrepPatternType int
+
+const (
+	// GrepBasicRegexp patterns are POSIX basic regular expressions, git's default.
+	GrepBasicRegexp GrepPatternType = iota
+	GrepExtendedRegexp
+	GrepFixedStrings
+	// GrepPerlRegexp patterns need git built with PCRE support.
+	GrepPerlRegexp
+)
+
+var grepPatternTypeOptions = map[GrepPatternType]string{
+	GrepBasicRegexp:    "--basic-regexp",
+	GrepExtendedRegexp: "--extended-regexp",
+	GrepFixedStrings:   "--fixed-strings",
+	GrepPerlRegexp:     "--perl-regexp",
+}
+
+// GrepOptions controls Grep.  By default the tracked files of the working tree are searched.
+type GrepOptions struct {
+	PatternType GrepPatternType
+	IgnoreCase  bool
+	// Revisions searches the trees of the given revisions instead of the working tree.
+	Revisions []string
+	// Pathspecs restricts the search to matching paths.
+	Pathspecs []string
+	// Untracked also searches untracked files in the working tree, other than ignored ones.
+	Untracked bool
+	// RecurseSubmodules also searches initialized submodules.
+	RecurseSubmodules bool
+}
+
+// GrepMatch is a line matching the pattern.  Revision is the revision searched, as given in GrepOptions, and is
+// empty for the working tree.  Path is relative to the directory Grep ran in, and includes the submodule's path for
+// matches in submodules.  Line and Column, the byte offset of the first match, start at 1.
+type GrepMatch struct {
+	Revision string
+	Path     string
+	Line     int
+	Column   int
+	Text     string
+}
+
+// GrepFunc receives matches as Grep finds them.  Returning an error stops the search.
+type GrepFunc func(match GrepMatch) error
+
+func Grep(exec Executor, pattern string, opts GrepOptions) ([]GrepMatch, error) {
+	// Returns every match, or an empty list when nothing matches.  GrepStream suits large result sets better.
+	matches := []GrepMatch{}
+	err := GrepStream(exec, pattern, opts, func(match GrepMatch) error {
+		matches = append(matches, match)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return matches, nil
+}
+
+func GrepStream(exec Executor, pattern string, opts GrepOptions, fn GrepFunc) error {
+	// Passes each match to fn as git reports it.  With -z each match is reported as:
+	// [<revision>:]<path> NUL <line> NUL <column> NUL <text> LF
+	// where the revision and path are joined by a colon.  Binary files are skipped.  Git exits 1 when nothing
+	// matches.
+	if err := checkArguments(opts.Revisions...); err != nil {
+		return err
+	}
+	cmdArr := []string{"git", "grep", "-z", "--line-number", "--column", "--no-color", "-I",
+		grepPatternTypeOptions[opts.PatternType]}
+	if opts.IgnoreCase {
+		cmdArr = append(cmdArr, "--ignore-case")
+	}
+	if opts.Untracked {
+		cmdArr = append(cmdArr, "--untracked")
+	}
+	if opts.RecurseSubmodules {
+		cmdArr = append(cmdArr, "--recurse-submodules")
+	}
+	cmdArr = append(cmdArr, "-e", pattern)
+	// grep does not accept --end-of-options, but checkArguments has ruled out revisions which look like options.
+	cmdArr = append(cmdArr, opts.Revisions...)
+	cmdArr = append(cmdArr, "--")
+	cmdArr = append(cmdArr, opts.Pathspecs...)
+	err := runAndStreamOutput(exec, cmdArr, func(line []byte) error {
+		match, err := parseGrepMatch(line, opts.Revisions)
+		if err != nil {
+			return err
+		}
+		return fn(match)
+	})
+	if exitCodeOf(err) == 1 && noStderr(err) {
+		return nil
+	}
+	return err
+}
+
+// parseGrepMatch parses a line of 'git grep -z' output, where the path is prefixed by one of revisions.
+func parseGrepMatch(line []byte, revisions []string) (GrepMatch, error) {
+	fields := bytes.SplitN(line, []byte{0}, 4)
+	if len(fields) != 4 {
+		return GrepMatch{}, errors.New("Unrecognized grep output: " + string(line))
+	}
+	match := GrepMatch{Path: string(fields[0]), Text: string(fields[3])}
+	// A revision may itself contain a colon, so the longest revision prefixing the path is the one searched.  Newer
+	// versions of git join trees given as <revision>:<path> to paths with a slash rather than a colon.
+	for _, revision := range revisions {
+		if len(revision) <= len(match.Revision) || !strings.HasPrefix(match.Path, revision) {
+			continue
+		}
+		rest := match.Path[len(revision):]
+		if strings.HasPrefix(rest, ":") || strings.HasPrefix(rest, "/") && strings.Contains(revision, ":") {
+			match.Revision = revision
+		}
+	}
+	if len(match.Revision) != 0 {
+		match.Path = match.Path[len(match.Revision)+1:]
+	}
+	var err error
+	if match.Line, err = strconv.Atoi(string(fields[1])); err != nil {
+		return GrepMatch{}, errors.New("Unrecognized grep output: " + string(line))
+	}
+	if match.Column, err = strconv.Atoi(string(fields[2])); err != nil {
+		return GrepMatch{}, errors.New("Unrecognized grep output: " + string(line))
+	}
+	return match, nil
+}
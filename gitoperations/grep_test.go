@@ -0,0 +1,92 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestGrep(t *testing.T) {
+	setup()
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{stdout: "" +
+			"v1.0:cmd/main.go\x0012\x005\x00\tfmt.Println(\"TODO: x\")\n" +
+			"v1.0:HEAD:a.go\x003\x001\x00// todo\n" +
+			"main:HEAD:a.go\x007\x002\x00 todo: y\n" +
+			"main:HEAD/b.go\x001\x001\x00todo\n"})
+		matches, err := Grep(mockGit, "-todo", GrepOptions{PatternType: GrepFixedStrings, IgnoreCase: true,
+			Revisions: []string{"v1.0", "main:HEAD", "main"}, Pathspecs: []string{"*.go"}})
+		if err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		expected := []GrepMatch{
+			{Revision: "v1.0", Path: "cmd/main.go", Line: 12, Column: 5, Text: "\tfmt.Println(\"TODO: x\")"},
+			{Revision: "v1.0", Path: "HEAD:a.go", Line: 3, Column: 1, Text: "// todo"},
+			{Revision: "main:HEAD", Path: "a.go", Line: 7, Column: 2, Text: " todo: y"},
+			{Revision: "main:HEAD", Path: "b.go", Line: 1, Column: 1, Text: "todo"},
+		}
+		if !reflect.DeepEqual(matches, expected) {
+			t.Fatalf("Expected %+v, but received %+v", expected, matches)
+		}
+		expectedCmd := []string{"git", "grep", "-z", "--line-number", "--column", "--no-color", "-I",
+			"--fixed-strings", "--ignore-case", "-e", "-todo", "v1.0", "main:HEAD", "main", "--", "*.go"}
+		if !reflect.DeepEqual(calls[0], expectedCmd) {
+			t.Fatalf("Expected %v, but received %v", expectedCmd, calls[0])
+		}
+	}
+	{ // Git exits 1 when nothing matches.
+		calls := [][]string{}
+		matches, err := Grep(createScriptedExecCommand(&calls, fakeResponse{exitStatus: 1}), "x",
+			GrepOptions{PatternType: GrepPerlRegexp, Untracked: true, RecurseSubmodules: true})
+		if err != nil || len(matches) != 0 {
+			t.Fatalf("Expected no matches and nil error, but received %v, %v", matches, err)
+		}
+		expectedCmd := []string{"git", "grep", "-z", "--line-number", "--column", "--no-color", "-I",
+			"--perl-regexp", "--untracked", "--recurse-submodules", "-e", "x", "--"}
+		if !reflect.DeepEqual(calls[0], expectedCmd) {
+			t.Fatalf("Expected %v, but received %v", expectedCmd, calls[0])
+		}
+	}
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls,
+			fakeResponse{stderr: "fatal: -e option, 'a(': Unmatched ( or \\(\n", exitStatus: 128})
+		if _, err := Grep(mockGit, "a(", GrepOptions{}); exitCodeOf(err) != 128 {
+			t.Fatalf("Expected exit status 128, but received %v", err)
+		}
+		if _, err := Grep(mockGit, "x", GrepOptions{Revisions: []string{"--all"}}); !errors.Is(err,
+			ErrUnsafeArgument) {
+			t.Fatalf("Expected ErrUnsafeArgument, but received %v", err)
+		}
+	}
+}
+
+func TestGrepStream(t *testing.T) {
+	setup()
+	calls := [][]string{}
+	mockGit := createScriptedExecCommand(&calls, fakeResponse{stdout: "" +
+		"a.go\x001\x001\x00x\n" +
+		"b.go\x002\x001\x00x\n" +
+		"c.go\x003\x001\x00x\n"})
+	stop := errors.New("stop")
+	paths := []string{}
+	err := GrepStream(mockGit, "x", GrepOptions{}, func(match GrepMatch) error {
+		paths = append(paths, match.Path)
+		if len(paths) == 2 {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || !reflect.DeepEqual(paths, []string{"a.go", "b.go"}) {
+		t.Fatalf("Expected to stop after b.go, but received %v, %v", paths, err)
+	}
+
+	mockGit = createScriptedExecCommand(&calls, fakeResponse{stdout: "Binary file a.bin matches\n"})
+	if err := GrepStream(mockGit, "x", GrepOptions{}, func(GrepMatch) error { return nil }); err == nil {
+		t.Fatalf("Expected non-nil error")
+	}
+}
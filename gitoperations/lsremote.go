@@ -0,0 +1,85 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"errors"
+	"strings"
+)
+
+// ListRemoteRefsOptions controls ListRemoteRefs.  Heads and Tags restrict the listing to branches and tags
+// respectively; when neither is set every ref is listed.
+type ListRemoteRefsOptions struct {
+	Heads bool
+	Tags  bool
+	// Symref reports the target of symbolic refs such as HEAD.
+	Symref bool
+}
+
+// RemoteRef is a ref advertised by a remote.  Peeled is the object an annotated tag points at, and is empty for other
+// refs.  SymrefTarget is the ref a symbolic ref such as HEAD points at, and is only reported when requested.
+type RemoteRef struct {
+	Name         string
+	ObjectID     ObjectID
+	Peeled       ObjectID
+	SymrefTarget string
+}
+
+// ListRemoteRefs lists the refs advertised by remoteOrURL without fetching anything.  When patterns are given only
+// refs whose names match one of them, compared from the end of the name at a slash boundary, are listed.
+func ListRemoteRefs(exec Executor, remoteOrURL string, patterns []string, opts ListRemoteRefsOptions) ([]RemoteRef, error) {
+	cmdArr := []string{"git", "ls-remote"}
+	if opts.Heads {
+		cmdArr = append(cmdArr, "--heads")
+	}
+	if opts.Tags {
+		cmdArr = append(cmdArr, "--tags")
+	}
+	if opts.Symref {
+		cmdArr = append(cmdArr, "--symref")
+	}
+	cmdArr = append(append(cmdArr, "--", remoteOrURL), patterns...)
+	out, err := runAndGetOutput(exec, cmdArr)
+	if err != nil {
+		return nil, err
+	}
+	return parseLsRemote(out)
+}
+
+func parseLsRemote(output []byte) ([]RemoteRef, error) {
+	// Lines look like one of:
+	// <oid> TAB <ref>
+	// <oid> TAB <tag>^{}          the object an annotated tag peels to, following the tag itself
+	// ref: <target> TAB <ref>     the target of a symbolic ref, preceding the ref itself (--symref only)
+	refs := []RemoteRef{}
+	indexByName := map[string]int{}
+	symrefTargets := map[string]string{}
+	scanner := scanAndSplit(output)
+	for scanner.Scan() {
+		line := scanner.Text()
+		fields := strings.SplitN(line, "\t", 2)
+		if len(fields) != 2 {
+			return nil, errors.New("Unrecognized ls-remote output: " + line)
+		}
+		value, name := fields[0], fields[1]
+		if strings.HasPrefix(value, "ref: ") {
+			symrefTargets[name] = strings.TrimPrefix(value, "ref: ")
+			continue
+		}
+		oid, err := ParseObjectID(value)
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasSuffix(name, "^{}") {
+			if i, ok := indexByName[strings.TrimSuffix(name, "^{}")]; ok {
+				refs[i].Peeled = oid
+				continue
+			}
+			return nil, errors.New("Peeled ref without its tag in ls-remote output: " + line)
+		}
+		indexByName[name] = len(refs)
+		refs = append(refs, RemoteRef{Name: name, ObjectID: oid, SymrefTarget: symrefTargets[name]})
+	}
+	return refs, nil
+}
@@ -0,0 +1,63 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestListRemoteRefs(t *testing.T) {
+	setup()
+	{
+		output := "ref: refs/heads/main\tHEAD\n" +
+			"a4f53383e4146778295e2943355d8ffd0be455d6\tHEAD\n" +
+			"a4f53383e4146778295e2943355d8ffd0be455d6\trefs/heads/main\n" +
+			"5d0f1e2a3b4c5d6e7f8091a2b3c4d5e6f7081920\trefs/tags/v1\n" +
+			"9b9ca25f4614cbbbe31b53f1b449ffec4f13cde4\trefs/tags/v1^{}\n"
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{stdout: output})
+		refs, err := ListRemoteRefs(mockGit, "origin", []string{"HEAD", "main", "v1"},
+			ListRemoteRefsOptions{Heads: true, Tags: true, Symref: true})
+		if err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		expectedCmd := []string{"git", "ls-remote", "--heads", "--tags", "--symref", "--", "origin", "HEAD", "main", "v1"}
+		if !reflect.DeepEqual(calls[0], expectedCmd) {
+			t.Fatalf("Expected %v, but received %v", expectedCmd, calls[0])
+		}
+		expected := []RemoteRef{
+			{Name: "HEAD", ObjectID: "a4f53383e4146778295e2943355d8ffd0be455d6", SymrefTarget: "refs/heads/main"},
+			{Name: "refs/heads/main", ObjectID: "a4f53383e4146778295e2943355d8ffd0be455d6"},
+			{Name: "refs/tags/v1", ObjectID: "5d0f1e2a3b4c5d6e7f8091a2b3c4d5e6f7081920",
+				Peeled: "9b9ca25f4614cbbbe31b53f1b449ffec4f13cde4"},
+		}
+		if !reflect.DeepEqual(refs, expected) {
+			t.Fatalf("Expected %+v, but received %+v", expected, refs)
+		}
+	}
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{})
+		refs, err := ListRemoteRefs(mockGit, "https://example.com/repo.git", nil, ListRemoteRefsOptions{})
+		if err != nil || len(refs) != 0 {
+			t.Fatalf("Expected no refs, but received %v, %v", refs, err)
+		}
+	}
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{stdout: "garbage\n"})
+		if _, err := ListRemoteRefs(mockGit, "origin", nil, ListRemoteRefsOptions{}); err == nil {
+			t.Fatalf("Expected non-nil error")
+		}
+	}
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls,
+			fakeResponse{stderr: "fatal: 'nowhere' does not appear to be a git repository\n", exitStatus: 128})
+		if _, err := ListRemoteRefs(mockGit, "nowhere", nil, ListRemoteRefsOptions{}); exitCodeOf(err) != 128 {
+			t.Fatalf("Expected exit status 128, but received %v", err)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidObjectID is returned, wrapped, by ParseObjectID for strings which are not full object ids.
+var ErrInvalidObjectID = errors.New("Invalid object id")
+
+// ObjectFormat is the hash algorithm a repository names its objects with.
+type ObjectFormat string
+
+const (
+	ObjectFormatSHA1   ObjectFormat = "sha1"
+	ObjectFormatSHA256 ObjectFormat = "sha256"
+)
+
+// HexLength returns the number of hexadecimal digits in the format's object ids, or 0 for unknown formats.
+func (format ObjectFormat) HexLength() int {
+	switch format {
+	case ObjectFormatSHA1:
+		return 40
+	case ObjectFormatSHA256:
+		return 64
+	}
+	return 0
+}
+
+// ObjectID is the full, lower case hexadecimal id of a commit, tree, blob or tag: 40 digits in SHA-1 repositories and
+// 64 in SHA-256 ones.  The empty ObjectID means there is no object, for example for a ref which did not exist.
+type ObjectID string
+
+// ParseObjectID validates s, ignoring surrounding white space, as a SHA-1 or SHA-256 object id.
+func ParseObjectID(s string) (ObjectID, error) {
+	s = strings.TrimSpace(s)
+	if len(s) != ObjectFormatSHA1.HexLength() && len(s) != ObjectFormatSHA256.HexLength() {
+		return "", fmt.Errorf("%w: %q", ErrInvalidObjectID, s)
+	}
+	for _, c := range s {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return "", fmt.Errorf("%w: %q", ErrInvalidObjectID, s)
+		}
+	}
+	return ObjectID(s), nil
+}
+
+// ZeroObjectID returns the id of all zeros which git uses to denote a missing object, such as the old value of a ref
+// being created.
+func ZeroObjectID(format ObjectFormat) ObjectID {
+	return ObjectID(strings.Repeat("0", format.HexLength()))
+}
+
+func (id ObjectID) String() string {
+	return string(id)
+}
+
+// Format returns the hash algorithm id belongs to, judging by its length, or "" for the empty ObjectID.
+func (id ObjectID) Format() ObjectFormat {
+	switch len(id) {
+	case ObjectFormatSHA1.HexLength():
+		return ObjectFormatSHA1
+	case ObjectFormatSHA256.HexLength():
+		return ObjectFormatSHA256
+	}
+	return ""
+}
+
+// IsZero reports whether id is the all zeros id.  The empty ObjectID is not considered zero.
+func (id ObjectID) IsZero() bool {
+	return len(id) != 0 && strings.Trim(string(id), "0") == ""
+}
+
+// Abbrev returns the first n digits of id for display, or id in full when it is shorter.  Unlike the abbreviations
+// git prints, the result is not guaranteed to be unambiguous.
+func (id ObjectID) Abbrev(n int) string {
+	if n < 0 || n >= len(id) {
+		return string(id)
+	}
+	return string(id[:n])
+}
+
+// parseObjectIDs parses each of ids, which may be empty to mean no object.
+func parseObjectIDs(ids ...string) ([]ObjectID, error) {
+	parsed := make([]ObjectID, len(ids))
+	for i, id := range ids {
+		if len(id) == 0 {
+			continue
+		}
+		var err error
+		if parsed[i], err = ParseObjectID(id); err != nil {
+			return nil, err
+		}
+	}
+	return parsed, nil
+}
+
+func GetObjectFormat(exec Executor) (ObjectFormat, error) {
+	// Reports the repository's extensions.objectFormat.  Git older than 2.29 cannot open SHA-256 repositories, so with
+	// those the format is SHA-1.
+	version, err := GetGitVersion(exec)
+	if err != nil {
+		return "", err
+	}
+	if !version.AtLeast(showObjectFormatMajor, showObjectFormatMinor) {
+		return ObjectFormatSHA1, nil
+	}
+	cmdArr := []string{"git", "rev-parse", "--show-object-format"}
+	out, err := runAndGetOutput(exec, cmdArr)
+	if err != nil {
+		return "", err
+	}
+	return ObjectFormat(strings.TrimSpace(string(out))), nil
+}
+
+func ResolveCommit(exec Executor, revision string) (ObjectID, error) {
+	// Resolves a revision such as HEAD, HEAD~, a branch or an abbreviated id to the id of the commit it names,
+	// peeling annotated tags.
+	if err := checkArguments(revision); err != nil {
+		return "", err
+	}
+	cmdArr := []string{"git", "rev-parse", "--verify", "--end-of-options", revision + "^{commit}"}
+	out, err := runAndGetOutput(exec, cmdArr)
+	if err != nil {
+		return "", err
+	}
+	return ParseObjectID(string(out))
+}
+
+func HeadCommit(exec Executor) (ObjectID, error) {
+	// Returns the id of the commit HEAD points to.
+	return ResolveCommit(exec, "HEAD")
+}
+
+func ParentCommit(exec Executor) (ObjectID, error) {
+	// Returns the id of the first parent (HEAD~) of the commit HEAD points to.
+	return ResolveCommit(exec, "HEAD~")
+}
+
+func LastCommitOnBranch(exec Executor, branch string) (ObjectID, error) {
+	// Returns the id of the commit at the tip of branch, which may be any revision naming a commit, such as
+	// origin/main.
+	return ResolveCommit(exec, branch)
+}
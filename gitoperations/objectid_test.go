@@ -0,0 +1,141 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+const (
+	testSHA1   = "7041aaf6b3ab17effd5c8cd7a4abfa3bdee2a988"
+	testSHA256 = "3c8b2f1e6d4a5b7c9e0f1a2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c4d5e6f"
+)
+
+func TestParseObjectID(t *testing.T) {
+	valid := map[string]ObjectID{
+		testSHA1:                        testSHA1,
+		testSHA256:                      testSHA256,
+		" " + testSHA1 + "\n":           testSHA1,
+		ZeroObjectID("sha256").String(): ZeroObjectID(ObjectFormatSHA256),
+	}
+	for input, expected := range valid {
+		id, err := ParseObjectID(input)
+		if err != nil || id != expected {
+			t.Errorf("Expected %q, but received %q, %v", expected, id, err)
+		}
+	}
+	invalid := []string{"", "7041aaf", testSHA1 + "0", "7041AAF6B3AB17EFFD5C8CD7A4ABFA3BDEE2A988",
+		"g041aaf6b3ab17effd5c8cd7a4abfa3bdee2a988"}
+	for _, input := range invalid {
+		if _, err := ParseObjectID(input); !errors.Is(err, ErrInvalidObjectID) {
+			t.Errorf("Expected ErrInvalidObjectID for %q, but received %v", input, err)
+		}
+	}
+}
+
+func TestObjectIDHelpers(t *testing.T) {
+	if zero := ZeroObjectID(ObjectFormatSHA1); zero != "0000000000000000000000000000000000000000" || !zero.IsZero() {
+		t.Errorf("Unexpected SHA-1 zero id %q", zero)
+	}
+	if zero := ZeroObjectID(ObjectFormatSHA256); len(zero) != 64 || !zero.IsZero() ||
+		zero.Format() != ObjectFormatSHA256 {
+		t.Errorf("Unexpected SHA-256 zero id %q", zero)
+	}
+	if ObjectID("").IsZero() || ObjectID(testSHA1).IsZero() {
+		t.Errorf("Expected only all zero ids to be zero")
+	}
+	if format := ObjectID(testSHA1).Format(); format != ObjectFormatSHA1 {
+		t.Errorf("Expected sha1, but received %q", format)
+	}
+	if format := ObjectID("").Format(); format != "" {
+		t.Errorf("Expected no format, but received %q", format)
+	}
+	if abbrev := ObjectID(testSHA256).Abbrev(7); abbrev != "3c8b2f1" {
+		t.Errorf("Expected 3c8b2f1, but received %q", abbrev)
+	}
+	if abbrev := ObjectID(testSHA1).Abbrev(100); abbrev != testSHA1 {
+		t.Errorf("Expected the full id, but received %q", abbrev)
+	}
+}
+
+func TestGetObjectFormat(t *testing.T) {
+	setup()
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls,
+			fakeResponse{stdout: "git version 2.39.5\n"},
+			fakeResponse{stdout: "sha256\n"})
+		format, err := GetObjectFormat(mockGit)
+		if err != nil || format != ObjectFormatSHA256 {
+			t.Fatalf("Expected sha256, but received %q, %v", format, err)
+		}
+		expectedCmd := []string{"git", "rev-parse", "--show-object-format"}
+		if !reflect.DeepEqual(calls[1], expectedCmd) {
+			t.Fatalf("Expected %v, but received %v", expectedCmd, calls[1])
+		}
+	}
+	{ // Older versions of git only support SHA-1.
+		calls := [][]string{}
+		format, err := GetObjectFormat(createScriptedExecCommand(&calls, fakeResponse{stdout: "git version 2.25.1\n"}))
+		if err != nil || format != ObjectFormatSHA1 {
+			t.Fatalf("Expected sha1, but received %q, %v", format, err)
+		}
+		if len(calls) != 1 {
+			t.Fatalf("Expected only the version to be checked, but received %v", calls)
+		}
+	}
+}
+
+func TestResolveCommit(t *testing.T) {
+	setup()
+	{
+		calls := [][]string{}
+		id, err := ResolveCommit(createScriptedExecCommand(&calls, fakeResponse{stdout: testSHA256 + "\n"}), "v1.0")
+		if err != nil || id != testSHA256 {
+			t.Fatalf("Expected %s, but received %q, %v", testSHA256, id, err)
+		}
+		expectedCmd := []string{"git", "rev-parse", "--verify", "--end-of-options", "v1.0^{commit}"}
+		if !reflect.DeepEqual(calls[0], expectedCmd) {
+			t.Fatalf("Expected %v, but received %v", expectedCmd, calls[0])
+		}
+	}
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls,
+			fakeResponse{stderr: "fatal: Needed a single revision\n", exitStatus: 128})
+		if _, err := ResolveCommit(mockGit, "HEAD~"); exitCodeOf(err) != 128 {
+			t.Fatalf("Expected exit status 128, but received %v", err)
+		}
+	}
+	{
+		calls := [][]string{}
+		if _, err := ResolveCommit(createScriptedExecCommand(&calls, fakeResponse{}), "--all"); !errors.Is(err,
+			ErrUnsafeArgument) {
+			t.Fatalf("Expected ErrUnsafeArgument, but received %v", err)
+		}
+	}
+	{ // The typed replacements of GetHeadCommit, GetParentCommit and GetLastCommitOnBranch.
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{stdout: testSHA1 + "\n"})
+		for _, resolve := range []func() (ObjectID, error){
+			func() (ObjectID, error) { return HeadCommit(mockGit) },
+			func() (ObjectID, error) { return ParentCommit(mockGit) },
+			func() (ObjectID, error) { return LastCommitOnBranch(mockGit, "origin/main") },
+		} {
+			if id, err := resolve(); err != nil || id != testSHA1 {
+				t.Fatalf("Expected %s, but received %q, %v", testSHA1, id, err)
+			}
+		}
+		expectedCmds := [][]string{
+			{"git", "rev-parse", "--verify", "--end-of-options", "HEAD^{commit}"},
+			{"git", "rev-parse", "--verify", "--end-of-options", "HEAD~^{commit}"},
+			{"git", "rev-parse", "--verify", "--end-of-options", "origin/main^{commit}"},
+		}
+		if !reflect.DeepEqual(calls, expectedCmds) {
+			t.Fatalf("Expected %v, but received %v", expectedCmds, calls)
+		}
+	}
+}
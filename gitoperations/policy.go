@@ -0,0 +1,149 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// PolicyRule names a rule of a HistoryPolicy.
+type PolicyRule string
+
+const (
+	PolicyNoMergeCommits      PolicyRule = "no-merge-commits"
+	PolicyMaxCommits          PolicyRule = "max-commits"
+	PolicySigned              PolicyRule = "signed"
+	PolicyMessagePattern      PolicyRule = "message-pattern"
+	PolicyConventionalCommits PolicyRule = "conventional-commits"
+	PolicyRequiredTrailers    PolicyRule = "required-trailers"
+	PolicyAuthorEmailDomains  PolicyRule = "author-email-domains"
+)
+
+// HistoryPolicy lists the rules CheckHistoryPolicy enforces.  The zero value enforces nothing.
+type HistoryPolicy struct {
+	NoMergeCommits bool
+	// MaxCommits limits the number of commits, when greater than 0.
+	MaxCommits int
+	// RequireSignatures requires a good signature from a trusted key on every commit, as VerifyRange checks.
+	RequireSignatures bool
+	// MessagePattern must match somewhere in each commit's full message.  Use (?m) to anchor to lines.
+	MessagePattern *regexp.Regexp
+	// ConventionalCommits requires subjects such as "fix(parser)!: handle empty input", as described at
+	// https://www.conventionalcommits.org.
+	ConventionalCommits bool
+	// RequiredTrailers lists trailer keys, such as "Signed-off-by", each commit must have.  Keys match case
+	// insensitively.
+	RequiredTrailers []string
+	// AuthorEmailDomains, when not empty, lists the domains author emails must be in.  Domains match case
+	// insensitively, and do not admit their subdomains.
+	AuthorEmailDomains []string
+}
+
+// PolicyViolation is a breach of a HistoryPolicy rule.  Commit is the offending commit, and is empty for rules about
+// the history as a whole, such as PolicyMaxCommits.
+type PolicyViolation struct {
+	Commit  ObjectID
+	Rule    PolicyRule
+	Message string
+}
+
+var conventionalCommitSubject = regexp.MustCompile(`^[A-Za-z]+(\([^()]+\))?!?: \S`)
+
+func CheckHistoryPolicy(exec Executor, base string, head string, policy HistoryPolicy) ([]PolicyViolation, error) {
+	// Checks every commit reachable from head but not from base, or from head alone when base is empty, and returns
+	// every violation, those of the newest commit first and those of the history as a whole last.  An empty result
+	// means the history complies.
+	// With -z each commit is reported as NUL terminated fields:
+	// <commit> NUL <parents> NUL <author email> NUL <message> NUL <trailers> NUL
+	// where the parents are separated by spaces and the trailers are unfolded, one "<key>: <value>" per line.
+	if err := checkArguments(base, head); err != nil {
+		return nil, err
+	}
+	revisions := head
+	if len(base) != 0 {
+		revisions = base + ".." + head
+	}
+	cmdArr := []string{"git", "log", "-z", "--format=%H%x00%P%x00%ae%x00%B%x00%(trailers:only,unfold)",
+		"--end-of-options", revisions}
+	out, err := runAndGetOutput(exec, cmdArr)
+	if err != nil {
+		return nil, err
+	}
+	records := splitNul(out)
+	if len(records)%5 != 0 {
+		return nil, errors.New("Unrecognized log output: " + string(out))
+	}
+	unverified := map[ObjectID]SignatureStatus{}
+	if policy.RequireSignatures {
+		signatures, err := VerifyRange(exec, base, head)
+		if err != nil {
+			return nil, err
+		}
+		for _, signature := range signatures {
+			unverified[signature.Commit] = signature.Signature.Status
+		}
+	}
+
+	violations := []PolicyViolation{}
+	for i := 0; i < len(records); i += 5 {
+		commit, err := ParseObjectID(records[i])
+		if err != nil {
+			return nil, err
+		}
+		violate := func(rule PolicyRule, format string, a ...interface{}) {
+			violations = append(violations, PolicyViolation{Commit: commit, Rule: rule,
+				Message: fmt.Sprintf(format, a...)})
+		}
+		parents, email, message, trailers := strings.Fields(records[i+1]), records[i+2], records[i+3], records[i+4]
+		if policy.NoMergeCommits && len(parents) > 1 {
+			violate(PolicyNoMergeCommits, "Merge commit with %d parents", len(parents))
+		}
+		if policy.MessagePattern != nil && !policy.MessagePattern.MatchString(message) {
+			violate(PolicyMessagePattern, "Message does not match %q", policy.MessagePattern.String())
+		}
+		subject := strings.SplitN(message, "\n", 2)[0]
+		if policy.ConventionalCommits && !conventionalCommitSubject.MatchString(subject) {
+			violate(PolicyConventionalCommits, "Subject is not a conventional commit: %q", subject)
+		}
+		keys := map[string]bool{}
+		for _, trailer := range strings.Split(trailers, "\n") {
+			if colon := strings.IndexByte(trailer, ':'); colon > 0 {
+				keys[strings.ToLower(strings.TrimSpace(trailer[:colon]))] = true
+			}
+		}
+		for _, key := range policy.RequiredTrailers {
+			if !keys[strings.ToLower(key)] {
+				violate(PolicyRequiredTrailers, "Missing trailer %q", key)
+			}
+		}
+		if len(policy.AuthorEmailDomains) != 0 && !inEmailDomains(email, policy.AuthorEmailDomains) {
+			violate(PolicyAuthorEmailDomains, "Author email %q is not in an allowed domain", email)
+		}
+		if status, ok := unverified[commit]; ok {
+			violate(PolicySigned, "Signature is %s", status)
+		}
+	}
+	if count := len(records) / 5; policy.MaxCommits > 0 && count > policy.MaxCommits {
+		violations = append(violations, PolicyViolation{Rule: PolicyMaxCommits,
+			Message: fmt.Sprintf("%d commits exceed the limit of %d", count, policy.MaxCommits)})
+	}
+	return violations, nil
+}
+
+// inEmailDomains reports whether email's domain is one of domains.
+func inEmailDomains(email string, domains []string) bool {
+	at := strings.LastIndexByte(email, '@')
+	if at < 0 {
+		return false
+	}
+	for _, domain := range domains {
+		if strings.EqualFold(email[at+1:], domain) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestCheckHistoryPolicy(t *testing.T) {
+	setup()
+	log := "" +
+		testSHA1 + "\x00" + testSHA256 + " " + testSHA256 + "\x00dev@Example.com\x00Merge branch 'topic'\n\x00\x00" +
+		testSHA256 + "\x00" + testSHA1 + "\x00dev@example.org\x00feat(parser)!: drop v1\n\nBody\n\n" +
+		"Signed-off-by: Dev <dev@example.org>\n\x00signed-off-by: Dev <dev@example.org>\n\x00"
+	policy := HistoryPolicy{NoMergeCommits: true, MaxCommits: 1, RequireSignatures: true,
+		MessagePattern: regexp.MustCompile(`(?m)^Body$`), ConventionalCommits: true,
+		RequiredTrailers: []string{"Signed-off-by"}, AuthorEmailDomains: []string{"example.com"}}
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{stdout: log},
+			fakeResponse{stdout: testSHA1 + "\x00N\x00\x00\x00\x00\x00" + testSHA256 + "\x00B\x00\x00\x00\x00\x00"})
+		violations, err := CheckHistoryPolicy(mockGit, "main", "topic", policy)
+		if err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		expected := []PolicyViolation{
+			{Commit: testSHA1, Rule: PolicyNoMergeCommits, Message: "Merge commit with 2 parents"},
+			{Commit: testSHA1, Rule: PolicyMessagePattern, Message: "Message does not match \"(?m)^Body$\""},
+			{Commit: testSHA1, Rule: PolicyConventionalCommits,
+				Message: "Subject is not a conventional commit: \"Merge branch 'topic'\""},
+			{Commit: testSHA1, Rule: PolicyRequiredTrailers, Message: "Missing trailer \"Signed-off-by\""},
+			{Commit: testSHA1, Rule: PolicySigned, Message: "Signature is unsigned"},
+			{Commit: testSHA256, Rule: PolicyAuthorEmailDomains,
+				Message: "Author email \"dev@example.org\" is not in an allowed domain"},
+			{Commit: testSHA256, Rule: PolicySigned, Message: "Signature is bad"},
+			{Rule: PolicyMaxCommits, Message: "2 commits exceed the limit of 1"},
+		}
+		if !reflect.DeepEqual(violations, expected) {
+			t.Fatalf("Expected %+v, but received %+v", expected, violations)
+		}
+		expectedCmd := []string{"git", "log", "-z", "--format=%H%x00%P%x00%ae%x00%B%x00%(trailers:only,unfold)",
+			"--end-of-options", "main..topic"}
+		if len(calls) != 2 || !reflect.DeepEqual(calls[0], expectedCmd) {
+			t.Fatalf("Expected %v first, but received %v", expectedCmd, calls)
+		}
+	}
+	{ // The zero policy checks nothing, and so does not verify signatures.
+		calls := [][]string{}
+		violations, err := CheckHistoryPolicy(createScriptedExecCommand(&calls, fakeResponse{stdout: log}), "", "HEAD",
+			HistoryPolicy{})
+		if err != nil || len(violations) != 0 || len(calls) != 1 {
+			t.Fatalf("Expected no violations, but received %v, %v", violations, err)
+		}
+		if calls[0][len(calls[0])-1] != "HEAD" {
+			t.Fatalf("Expected HEAD alone, but received %v", calls[0])
+		}
+	}
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{stdout: testSHA1 + "\x00\x00"})
+		if _, err := CheckHistoryPolicy(mockGit, "", "HEAD", policy); err == nil {
+			t.Fatalf("Expected non-nil error")
+		}
+		if _, err := CheckHistoryPolicy(mockGit, "--all", "HEAD", policy); !errors.Is(err, ErrUnsafeArgument) {
+			t.Fatalf("Expected ErrUnsafeArgument, but received %v", err)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ProgressEvent is a single progress update reported by a long running git operation, for example
+// "Receiving objects:  45% (450/1000), 1.20 MiB | 2.40 MiB/s".
+// Phases which do not know their total, such as "Enumerating objects: 13", report only Current, with Percent and
+// Total set to -1.  Bytes and BytesPerSecond are -1 when the phase does not report a transfer.
+type ProgressEvent struct {
+	Phase string
+	// Remote is true for phases which run on the remote side, which git prefixes with "remote: ".
+	Remote         bool
+	Percent        int
+	Current        int64
+	Total          int64
+	Bytes          int64
+	BytesPerSecond int64
+	// Done is true for the final update of a phase.
+	Done bool
+}
+
+// ProgressFunc receives progress events as git reports them.
+type ProgressFunc func(ProgressEvent)
+
+var reForProgress = regexp.MustCompile(
+	`^([A-Z][A-Za-z ]*):\s+(?:(\d+)%\s+\((\d+)/(\d+)\)|(\d+))` +
+		`(?:,\s+([\d.]+ (?:[KMGT]iB|bytes?))(?:\s+\|\s+([\d.]+ (?:[KMGT]iB|bytes?))/s)?)?(,\s+done\.?)?`)
+
+var byteUnits = map[string]float64{
+	"byte":  1,
+	"bytes": 1,
+	"KiB":   1 << 10,
+	"MiB":   1 << 20,
+	"GiB":   1 << 30,
+	"TiB":   1 << 40,
+}
+
+// parseProgressLine parses a single line of progress output, returning false for lines which are not progress.
+func parseProgressLine(line string) (ProgressEvent, bool) {
+	event := ProgressEvent{Percent: -1, Total: -1, Bytes: -1, BytesPerSecond: -1}
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "remote: ") {
+		event.Remote = true
+		line = strings.TrimSpace(strings.TrimPrefix(line, "remote: "))
+	}
+	matched := reForProgress.FindStringSubmatch(line)
+	if matched == nil {
+		return event, false
+	}
+	event.Phase = matched[1]
+	if len(matched[2]) != 0 {
+		event.Percent, _ = strconv.Atoi(matched[2])
+		event.Current, _ = strconv.ParseInt(matched[3], 10, 64)
+		event.Total, _ = strconv.ParseInt(matched[4], 10, 64)
+	} else {
+		event.Current, _ = strconv.ParseInt(matched[5], 10, 64)
+	}
+	if len(matched[6]) != 0 {
+		event.Bytes = parseByteQuantity(matched[6])
+	}
+	if len(matched[7]) != 0 {
+		event.BytesPerSecond = parseByteQuantity(matched[7])
+	}
+	event.Done = len(matched[8]) != 0
+	return event, true
+}
+
+// parseByteQuantity converts a quantity such as "1.20 MiB" into bytes.
+func parseByteQuantity(quantity string) int64 {
+	fields := strings.Fields(quantity)
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil || len(fields) != 2 {
+		return -1
+	}
+	return int64(value * byteUnits[fields[1]])
+}
+
+// progressWriter splits what git writes to stderr into lines, which progress output terminates with a carriage return
+// so terminals redraw them in place, and passes every progress line to a ProgressFunc.
+type progressWriter struct {
+	progress ProgressFunc
+	pending  []byte
+}
+
+func (writer *progressWriter) Write(p []byte) (int, error) {
+	writer.pending = append(writer.pending, p...)
+	for {
+		end := bytes.IndexAny(writer.pending, "\r\n")
+		if end < 0 {
+			break
+		}
+		writer.emit(string(writer.pending[:end]))
+		writer.pending = writer.pending[end+1:]
+	}
+	return len(p), nil
+}
+
+// Flush reports any final line which was not terminated.
+func (writer *progressWriter) Flush() {
+	if len(writer.pending) != 0 {
+		writer.emit(string(writer.pending))
+		writer.pending = nil
+	}
+}
+
+func (writer *progressWriter) emit(line string) {
+	if event, ok := parseProgressLine(line); ok {
+		writer.progress(event)
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseProgressLine(t *testing.T) {
+	tests := map[string]ProgressEvent{
+		"remote: Enumerating objects: 13, done.        ": {Phase: "Enumerating objects", Remote: true, Percent: -1,
+			Current: 13, Total: -1, Bytes: -1, BytesPerSecond: -1, Done: true},
+		"remote: Counting objects:  46% (6/13)        ": {Phase: "Counting objects", Remote: true, Percent: 46,
+			Current: 6, Total: 13, Bytes: -1, BytesPerSecond: -1},
+		"Receiving objects:  45% (450/1000), 1.50 MiB | 2.00 KiB/s": {Phase: "Receiving objects", Percent: 45,
+			Current: 450, Total: 1000, Bytes: 1572864, BytesPerSecond: 2048},
+		"Writing objects: 100% (3/3), 250 bytes | 250.00 KiB/s, done.": {Phase: "Writing objects", Percent: 100,
+			Current: 3, Total: 3, Bytes: 250, BytesPerSecond: 256000, Done: true},
+		"Resolving deltas: 100% (20/20), done.": {Phase: "Resolving deltas", Percent: 100, Current: 20, Total: 20,
+			Bytes: -1, BytesPerSecond: -1, Done: true},
+	}
+	for line, expected := range tests {
+		event, ok := parseProgressLine(line)
+		if !ok || event != expected {
+			t.Errorf("For '%s' expected %+v, but received %+v, %v", line, expected, event, ok)
+		}
+	}
+	for _, line := range []string{"Cloning into 'repo'...", "From https://example.com/repo", "", "remote: nope"} {
+		if event, ok := parseProgressLine(line); ok {
+			t.Errorf("Expected '%s' not to be progress, but received %+v", line, event)
+		}
+	}
+}
+
+func TestProgressWriter(t *testing.T) {
+	events := []ProgressEvent{}
+	writer := &progressWriter{progress: func(event ProgressEvent) { events = append(events, event) }}
+	writer.Write([]byte("Cloning into 'repo'...\nReceiving objects:  50% (1/2)\rReceiving obj"))
+	writer.Write([]byte("ects: 100% (2/2), done.\nResolving deltas:   0% (0/1)"))
+	writer.Flush()
+	phases := []string{}
+	for _, event := range events {
+		phases = append(phases, event.Phase)
+	}
+	expected := []string{"Receiving objects", "Receiving objects", "Resolving deltas"}
+	if !reflect.DeepEqual(phases, expected) {
+		t.Fatalf("Expected %v, but received %v", expected, phases)
+	}
+	if events[0].Done || !events[1].Done || events[2].Percent != 0 {
+		t.Fatalf("Unexpected events %+v", events)
+	}
+}
+
+func TestCloneReportsProgress(t *testing.T) {
+	setup()
+	calls := [][]string{}
+	mockGit := createScriptedExecCommand(&calls,
+		fakeResponse{stderr: "Cloning into 'work'...\nReceiving objects: 100% (3/3), done.\n"})
+	events := []ProgressEvent{}
+	opts := CloneOptions{Progress: func(event ProgressEvent) { events = append(events, event) }}
+	if err := Clone(mockGit, "https://example.com/repo.git", "work", opts); err != nil {
+		t.Fatalf("Expected nil error, but received %v", err)
+	}
+	expectedCmd := []string{"git", "clone", "--progress", "--", "https://example.com/repo.git", "work"}
+	if !reflect.DeepEqual(calls[0], expectedCmd) {
+		t.Fatalf("Expected %v, but received %v", expectedCmd, calls[0])
+	}
+	if len(events) != 1 || events[0].Phase != "Receiving objects" || !events[0].Done {
+		t.Fatalf("Unexpected events %+v", events)
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"errors"
+	"strings"
+)
+
+// PushLease is an explicit expectation for --force-with-lease: the push only succeeds when Ref on the remote is
+// currently at Expected.  An empty Expected requires that the ref does not yet exist.
+type PushLease struct {
+	Ref      string
+	Expected string
+}
+
+// PushOptions controls PushRemote.  The zero value pushes like a bare 'git push <remote> <refspec>...'.
+type PushOptions struct {
+	Force bool
+	// ForceWithLease forces the update only while the remote refs still match the remote-tracking refs.  Leases
+	// replaces that check with explicit expectations, and implies ForceWithLease.
+	ForceWithLease bool
+	Leases         []PushLease
+	// Atomic requests that the remote update either all refs or none of them.
+	Atomic bool
+	// Options are sent to the server with --push-option.
+	Options []string
+	Tags    bool
+	// Delete deletes the refs named by refspecs from the remote.
+	Delete bool
+	// Progress, when non-nil, receives progress as the push proceeds.
+	Progress ProgressFunc
+}
+
+// PushStatus classifies the outcome of pushing a single ref.
+type PushStatus int
+
+const (
+	PushFastForward PushStatus = iota
+	PushForced
+	PushNew
+	PushDeleted
+	PushUpToDate
+	PushRejected
+	PushRemoteRejected
+)
+
+var pushStatusNames = map[PushStatus]string{
+	PushFastForward:    "fast-forward",
+	PushForced:         "forced",
+	PushNew:            "new",
+	PushDeleted:        "deleted",
+	PushUpToDate:       "up to date",
+	PushRejected:       "rejected",
+	PushRemoteRejected: "remote rejected",
+}
+
+func (status PushStatus) String() string {
+	if name, ok := pushStatusNames[status]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// PushResult describes the outcome of pushing a single ref.  Reason holds the parenthesized explanation git gives, for
+// example "non-fast-forward", "fetch first" or "stale info" for rejections, or the message of a remote hook which
+// declined the update.
+type PushResult struct {
+	Status      PushStatus
+	Source      string
+	Destination string
+	Summary     string
+	Reason      string
+}
+
+// Succeeded reports whether the remote ref now has the pushed value.
+func (result PushResult) Succeeded() bool {
+	return result.Status != PushRejected && result.Status != PushRemoteRejected
+}
+
+// PushRemote pushes refspecs to remote and reports the outcome for each ref.  When any ref is rejected git exits
+// unsuccessfully, and the results are returned together with the error so callers can tell which refs failed and why.
+func PushRemote(exec Executor, remote string, refspecs []string, opts PushOptions) ([]PushResult, error) {
+	if len(remote) == 0 {
+		return nil, errors.New("A remote is required to push.")
+	}
+	cmdArr := append([]string{"git", "push", "--porcelain"}, pushOptionArgs(opts)...)
+	cmdArr = append(append(cmdArr, "--", remote), refspecs...)
+	stdout, _, err := runAndReportProgress(exec, cmdArr, opts.Progress)
+	results, parseErr := parsePushPorcelain(stdout)
+	if err != nil {
+		return results, err
+	}
+	return results, parseErr
+}
+
+func pushOptionArgs(opts PushOptions) []string {
+	args := []string{}
+	if opts.Force {
+		args = append(args, "--force")
+	}
+	for _, lease := range opts.Leases {
+		args = append(args, "--force-with-lease="+lease.Ref+":"+lease.Expected)
+	}
+	if opts.ForceWithLease && len(opts.Leases) == 0 {
+		args = append(args, "--force-with-lease")
+	}
+	if opts.Atomic {
+		args = append(args, "--atomic")
+	}
+	for _, option := range opts.Options {
+		args = append(args, "--push-option="+option)
+	}
+	if opts.Tags {
+		args = append(args, "--tags")
+	}
+	if opts.Delete {
+		args = append(args, "--delete")
+	}
+	if opts.Progress != nil {
+		args = append(args, "--progress")
+	}
+	return args
+}
+
+var pushStatusesByCode = map[byte]PushStatus{
+	' ': PushFastForward,
+	'+': PushForced,
+	'*': PushNew,
+	'-': PushDeleted,
+	'=': PushUpToDate,
+	'!': PushRejected,
+}
+
+func parsePushPorcelain(output []byte) ([]PushResult, error) {
+	// The output looks like:
+	// To https://example.com/repo.git
+	// <flag> TAB <from>:<to> TAB <summary> [(<reason>)]
+	// Done
+	results := []PushResult{}
+	scanner := scanAndSplit(output)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "To ") || line == "Done" || len(line) == 0 {
+			continue
+		}
+		fields := strings.SplitN(line, "\t", 3)
+		status, ok := pushStatusesByCode[line[0]]
+		if !ok || len(fields) != 3 || len(fields[0]) != 1 {
+			return results, errors.New("Unrecognized push output: " + line)
+		}
+		result := PushResult{Status: status, Summary: fields[2]}
+		if colon := strings.IndexByte(fields[1], ':'); colon >= 0 {
+			result.Source, result.Destination = fields[1][:colon], fields[1][colon+1:]
+		} else {
+			result.Destination = fields[1]
+		}
+		if open := strings.Index(result.Summary, " ("); open >= 0 && strings.HasSuffix(result.Summary, ")") {
+			result.Reason = result.Summary[open+2 : len(result.Summary)-1]
+			result.Summary = result.Summary[:open]
+		}
+		if result.Status == PushRejected && result.Summary == "[remote rejected]" {
+			result.Status = PushRemoteRejected
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
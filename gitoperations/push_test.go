@@ -0,0 +1,106 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPushRemote(t *testing.T) {
+	setup()
+	{
+		output := "To https://example.com/repo.git\n" +
+			" \trefs/heads/main:refs/heads/main\td213512..a4f5338\n" +
+			"+\trefs/heads/topic:refs/heads/topic\t2539fe6...3fdc3a4 (forced update)\n" +
+			"*\trefs/tags/v1:refs/tags/v1\t[new tag]\n" +
+			"-\t:refs/heads/old\t[deleted]\n" +
+			"=\trefs/heads/stable:refs/heads/stable\t[up to date]\n" +
+			"Done\n"
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{stdout: output})
+		opts := PushOptions{
+			Leases:  []PushLease{{"refs/heads/topic", "2539fe6c0a1b2c3d4e5f60718293a4b5c6d7e8f9"}, {"refs/heads/new", ""}},
+			Atomic:  true,
+			Options: []string{"ci.skip", "merge_request.create"},
+		}
+		results, err := PushRemote(mockGit, "origin", []string{"main", "+topic"}, opts)
+		if err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		expectedCmd := []string{"git", "push", "--porcelain",
+			"--force-with-lease=refs/heads/topic:2539fe6c0a1b2c3d4e5f60718293a4b5c6d7e8f9",
+			"--force-with-lease=refs/heads/new:", "--atomic", "--push-option=ci.skip",
+			"--push-option=merge_request.create", "--", "origin", "main", "+topic"}
+		if !reflect.DeepEqual(calls[0], expectedCmd) {
+			t.Fatalf("Expected %v, but received %v", expectedCmd, calls[0])
+		}
+		expected := []PushResult{
+			{PushFastForward, "refs/heads/main", "refs/heads/main", "d213512..a4f5338", ""},
+			{PushForced, "refs/heads/topic", "refs/heads/topic", "2539fe6...3fdc3a4", "forced update"},
+			{PushNew, "refs/tags/v1", "refs/tags/v1", "[new tag]", ""},
+			{PushDeleted, "", "refs/heads/old", "[deleted]", ""},
+			{PushUpToDate, "refs/heads/stable", "refs/heads/stable", "[up to date]", ""},
+		}
+		if !reflect.DeepEqual(results, expected) {
+			t.Fatalf("Expected %+v, but received %+v", expected, results)
+		}
+		for _, result := range results {
+			if !result.Succeeded() {
+				t.Errorf("Expected %+v to have succeeded", result)
+			}
+		}
+	}
+	{ // Rejections are reported along with the error.
+		output := "To https://example.com/repo.git\n" +
+			"!\trefs/heads/main:refs/heads/main\t[rejected] (non-fast-forward)\n" +
+			"!\trefs/heads/topic:refs/heads/topic\t[rejected] (stale info)\n" +
+			"!\trefs/heads/dev:refs/heads/dev\t[remote rejected] (pre-receive hook declined)\n" +
+			"Done\n"
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls,
+			fakeResponse{stdout: output, stderr: "error: failed to push some refs\n", exitStatus: 1})
+		results, err := PushRemote(mockGit, "origin", []string{"main", "topic", "dev"},
+			PushOptions{ForceWithLease: true, Tags: true})
+		if err == nil || exitCodeOf(err) != 1 {
+			t.Fatalf("Expected exit status 1, but received %v", err)
+		}
+		expectedCmd := []string{"git", "push", "--porcelain", "--force-with-lease", "--tags", "--", "origin",
+			"main", "topic", "dev"}
+		if !reflect.DeepEqual(calls[0], expectedCmd) {
+			t.Fatalf("Expected %v, but received %v", expectedCmd, calls[0])
+		}
+		expected := []PushResult{
+			{PushRejected, "refs/heads/main", "refs/heads/main", "[rejected]", "non-fast-forward"},
+			{PushRejected, "refs/heads/topic", "refs/heads/topic", "[rejected]", "stale info"},
+			{PushRemoteRejected, "refs/heads/dev", "refs/heads/dev", "[remote rejected]", "pre-receive hook declined"},
+		}
+		if !reflect.DeepEqual(results, expected) {
+			t.Fatalf("Expected %+v, but received %+v", expected, results)
+		}
+		for _, result := range results {
+			if result.Succeeded() {
+				t.Errorf("Expected %+v to have failed", result)
+			}
+		}
+	}
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{})
+		_, err := PushRemote(mockGit, "origin", []string{"old"}, PushOptions{Force: true, Delete: true})
+		if err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		expectedCmd := []string{"git", "push", "--porcelain", "--force", "--delete", "--", "origin", "old"}
+		if !reflect.DeepEqual(calls[0], expectedCmd) {
+			t.Fatalf("Expected %v, but received %v", expectedCmd, calls[0])
+		}
+	}
+	{
+		calls := [][]string{}
+		if _, err := PushRemote(createScriptedExecCommand(&calls, fakeResponse{}), "", nil, PushOptions{}); err == nil {
+			t.Fatalf("Expected non-nil error")
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"errors"
+	"strings"
+)
+
+// Remote describes a configured remote.  PushURLs holds the explicitly configured push URLs; when it is empty git
+// pushes to FetchURLs.
+type Remote struct {
+	Name          string
+	FetchURLs     []string
+	PushURLs      []string
+	FetchRefspecs []string
+	PushRefspecs  []string
+}
+
+func ListRemotes(exec Executor) ([]Remote, error) {
+	// Reads the remote.<name>.* settings, which 'git config -z --get-regexp' reports as:
+	// <key> LF <value> NUL
+	// Remote names may themselves contain dots, so the variable name is taken from the last dot of the key.
+	// Remotes are returned in the order they first appear in the configuration.
+	cmdArr := []string{"git", "config", "-z", "--get-regexp", `^remote\..*\.(url|pushurl|fetch|push)$`}
+	out, err := runAndGetOutput(exec, cmdArr)
+	if err != nil {
+		// git config exits with status 1 when no key matches.
+		if exitCodeOf(err) == 1 {
+			return []Remote{}, nil
+		}
+		return nil, err
+	}
+	remotes := []Remote{}
+	indexByName := map[string]int{}
+	for _, record := range splitNul(out) {
+		newline := strings.IndexByte(record, '\n')
+		if newline < 0 {
+			return nil, errors.New("Unrecognized config output: " + record)
+		}
+		key, value := record[:newline], record[newline+1:]
+		dot := strings.LastIndexByte(key, '.')
+		if !strings.HasPrefix(key, "remote.") || dot <= len("remote.") {
+			return nil, errors.New("Unrecognized config key: " + key)
+		}
+		name, variable := key[len("remote."):dot], strings.ToLower(key[dot+1:])
+		i, ok := indexByName[name]
+		if !ok {
+			i = len(remotes)
+			indexByName[name] = i
+			remotes = append(remotes, Remote{Name: name})
+		}
+		remote := &remotes[i]
+		switch variable {
+		case "url":
+			remote.FetchURLs = append(remote.FetchURLs, value)
+		case "pushurl":
+			remote.PushURLs = append(remote.PushURLs, value)
+		case "fetch":
+			remote.FetchRefspecs = append(remote.FetchRefspecs, value)
+		case "push":
+			remote.PushRefspecs = append(remote.PushRefspecs, value)
+		}
+	}
+	return remotes, nil
+}
+
+func AddRemote(exec Executor, name string, url string) error {
+	cmdArr := []string{"git", "remote", "add", "--", name, url}
+	_, err := runAndGetOutput(exec, cmdArr)
+	return err
+}
+
+func RemoveRemote(exec Executor, name string) error {
+	cmdArr := []string{"git", "remote", "remove", "--", name}
+	_, err := runAndGetOutput(exec, cmdArr)
+	return err
+}
+
+func SetRemoteURL(exec Executor, name string, url string, push bool) error {
+	// Replaces the fetch URL of the remote, or its push URL when push is true.
+	cmdArr := []string{"git", "remote", "set-url"}
+	if push {
+		cmdArr = append(cmdArr, "--push")
+	}
+	cmdArr = append(cmdArr, "--", name, url)
+	_, err := runAndGetOutput(exec, cmdArr)
+	return err
+}
+
+func RenameRemote(exec Executor, oldName string, newName string) error {
+	cmdArr := []string{"git", "remote", "rename", "--", oldName, newName}
+	_, err := runAndGetOutput(exec, cmdArr)
+	return err
+}
+
+func SetRemoteHead(exec Executor, name string) error {
+	// Queries the remote for its default branch and points refs/remotes/<name>/HEAD at it.
+	cmdArr := []string{"git", "remote", "set-head", "--auto", "--", name}
+	_, err := runAndGetOutput(exec, cmdArr)
+	return err
+}
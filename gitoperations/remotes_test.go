@@ -0,0 +1,94 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestListRemotes(t *testing.T) {
+	setup()
+	{
+		output := "remote.origin.url\nhttps://example.com/repo.git\x00" +
+			"remote.origin.fetch\n+refs/heads/*:refs/remotes/origin/*\x00" +
+			"remote.fork.v2.url\ngit@example.com:me/repo.git\x00" +
+			"remote.fork.v2.pushurl\ngit@example.com:me/push.git\x00" +
+			"remote.origin.fetch\n+refs/notes/*:refs/notes/*\x00" +
+			"remote.fork.v2.push\nrefs/heads/dev:refs/heads/dev\x00"
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{stdout: output})
+		remotes, err := ListRemotes(mockGit)
+		if err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		expected := []Remote{
+			{
+				Name:          "origin",
+				FetchURLs:     []string{"https://example.com/repo.git"},
+				FetchRefspecs: []string{"+refs/heads/*:refs/remotes/origin/*", "+refs/notes/*:refs/notes/*"},
+			},
+			{
+				Name:         "fork.v2",
+				FetchURLs:    []string{"git@example.com:me/repo.git"},
+				PushURLs:     []string{"git@example.com:me/push.git"},
+				PushRefspecs: []string{"refs/heads/dev:refs/heads/dev"},
+			},
+		}
+		if !reflect.DeepEqual(remotes, expected) {
+			t.Fatalf("Expected %+v, but received %+v", expected, remotes)
+		}
+	}
+	{ // git config exits 1 when there are no remotes.
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{exitStatus: 1})
+		remotes, err := ListRemotes(mockGit)
+		if err != nil || len(remotes) != 0 {
+			t.Fatalf("Expected no remotes, but received %v, %v", remotes, err)
+		}
+	}
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{exitStatus: 3})
+		if _, err := ListRemotes(mockGit); err == nil {
+			t.Fatalf("Expected non-nil error")
+		}
+	}
+}
+
+func TestRemoteEditing(t *testing.T) {
+	setup()
+	type cmd struct {
+		f        func(exec Executor) error
+		expected []string
+	}
+	functions := []cmd{
+		{func(exec Executor) error { return AddRemote(exec, "upstream", "https://example.com/r.git") },
+			[]string{"git", "remote", "add", "--", "upstream", "https://example.com/r.git"}},
+		{func(exec Executor) error { return RemoveRemote(exec, "upstream") },
+			[]string{"git", "remote", "remove", "--", "upstream"}},
+		{func(exec Executor) error { return SetRemoteURL(exec, "upstream", "https://example.com/n.git", false) },
+			[]string{"git", "remote", "set-url", "--", "upstream", "https://example.com/n.git"}},
+		{func(exec Executor) error { return SetRemoteURL(exec, "upstream", "https://example.com/p.git", true) },
+			[]string{"git", "remote", "set-url", "--push", "--", "upstream", "https://example.com/p.git"}},
+		{func(exec Executor) error { return RenameRemote(exec, "upstream", "mirror") },
+			[]string{"git", "remote", "rename", "--", "upstream", "mirror"}},
+		{func(exec Executor) error { return SetRemoteHead(exec, "origin") },
+			[]string{"git", "remote", "set-head", "--auto", "--", "origin"}},
+	}
+	for _, fn := range functions {
+		calls := [][]string{}
+		if err := fn.f(createScriptedExecCommand(&calls, fakeResponse{})); err != nil {
+			t.Errorf("Expected nil error, but received %v", err)
+		}
+		if !reflect.DeepEqual(calls[0], fn.expected) {
+			t.Errorf("Expected %v, but received %v", fn.expected, calls[0])
+		}
+		calls = [][]string{}
+		err := fn.f(createScriptedExecCommand(&calls, fakeResponse{stderr: "error: No such remote\n", exitStatus: 2}))
+		if err == nil || exitCodeOf(err) != 2 {
+			t.Errorf("Expected exit status 2, but received %v", err)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// ErrNotARepository is returned, wrapped, by DiscoverRepository for paths which are not inside a git repository.
+var ErrNotARepository = errors.New("Not a git repository")
+
+// RepositoryInfo describes the repository containing a path and where the path lies within it.  All paths are
+// absolute.
+type RepositoryInfo struct {
+	// TopLevel is the root of the working tree, and is empty when the path is not inside a working tree, for example
+	// in a bare repository.
+	TopLevel string
+	GitDir   string
+	// CommonDir is the directory shared by all of the repository's worktrees.  It differs from GitDir in linked
+	// worktrees.
+	CommonDir      string
+	Bare           bool
+	Shallow        bool
+	InsideGitDir   bool
+	InsideWorkTree bool
+	// SuperprojectWorkTree is the root of the superproject's working tree when the repository is a submodule.
+	SuperprojectWorkTree string
+	ObjectFormat         ObjectFormat
+	// Prefix is the path's directory relative to TopLevel, ending with a slash, or empty at the top level.
+	Prefix string
+	Head   HeadState
+}
+
+// HeadState describes what HEAD points to.  Branch is the full ref name of the checked out branch and is empty when
+// HEAD is detached.  The branch is unborn when it has no commits yet, as in a new repository.
+type HeadState struct {
+	Branch   string
+	Detached bool
+	Unborn   bool
+}
+
+// The minimum git version which supports 'git rev-parse --show-object-format'.  Older versions only support SHA-1
+// repositories.
+const showObjectFormatMajor, showObjectFormatMinor = 2, 29
+
+func DiscoverRepository(exec Executor, path string) (RepositoryInfo, error) {
+	// Gathers everything with one rev-parse, which prints a line for each option in the order given:
+	// <git dir> LF <common dir> LF true|false LF ... <prefix> LF [<object format> LF]
+	// followed by lines which are only printed in some circumstances:
+	// [<cdup> LF] [<superproject working tree> LF] [<HEAD's full ref name>|HEAD LF]
+	// --show-cdup only prints inside a working tree and --show-superproject-working-tree only for submodules.  With
+	// --revs-only an unborn HEAD is omitted rather than failing the command, its branch then being read with
+	// symbolic-ref, and a detached HEAD prints as "HEAD".
+	// Relative paths are reported relative to the directory rev-parse runs in, which is made absolute so that they
+	// can be resolved.
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return RepositoryInfo{}, err
+	}
+	version, err := GetGitVersion(exec)
+	if err != nil {
+		return RepositoryInfo{}, err
+	}
+	options := []string{"--absolute-git-dir", "--git-common-dir", "--is-bare-repository", "--is-shallow-repository",
+		"--is-inside-git-dir", "--is-inside-work-tree", "--show-prefix"}
+	showObjectFormat := version.AtLeast(showObjectFormatMajor, showObjectFormatMinor)
+	if showObjectFormat {
+		options = append(options, "--show-object-format")
+	}
+	cmdArr := append([]string{"git", "-C", absPath, "rev-parse", "--revs-only"}, options...)
+	cmdArr = append(cmdArr, "--show-cdup", "--show-superproject-working-tree", "--symbolic-full-name", "HEAD")
+	out, err := runAndGetOutput(exec, cmdArr)
+	if err != nil {
+		var gitErr *GitError
+		if errors.As(err, &gitErr) && strings.Contains(gitErr.Stderr, "not a git repository") {
+			return RepositoryInfo{}, fmt.Errorf("%w: %s", ErrNotARepository, absPath)
+		}
+		return RepositoryInfo{}, err
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
+	if len(lines) < len(options) {
+		return RepositoryInfo{}, errors.New("Unrecognized rev-parse output: " + string(out))
+	}
+	info := RepositoryInfo{
+		GitDir:         lines[0],
+		CommonDir:      resolvePath(absPath, lines[1]),
+		Bare:           lines[2] == "true",
+		Shallow:        lines[3] == "true",
+		InsideGitDir:   lines[4] == "true",
+		InsideWorkTree: lines[5] == "true",
+		Prefix:         lines[6],
+		ObjectFormat:   ObjectFormatSHA1,
+	}
+	if showObjectFormat {
+		info.ObjectFormat = ObjectFormat(lines[7])
+	}
+	lines = lines[len(options):]
+	if info.InsideWorkTree {
+		if len(lines) == 0 {
+			return RepositoryInfo{}, errors.New("Unrecognized rev-parse output: " + string(out))
+		}
+		info.TopLevel = resolvePath(absPath, lines[0])
+		lines = lines[1:]
+	}
+	// The superproject's working tree is absolute, unlike what is printed for HEAD.
+	if len(lines) != 0 && filepath.IsAbs(lines[0]) {
+		info.SuperprojectWorkTree = lines[0]
+		lines = lines[1:]
+	}
+	switch {
+	case len(lines) == 0:
+		info.Head.Unborn = true
+		if info.Head.Branch, err = getUnbornBranch(exec, absPath); err != nil {
+			return RepositoryInfo{}, err
+		}
+	case lines[0] == "HEAD":
+		info.Head.Detached = true
+	default:
+		info.Head.Branch = lines[0]
+	}
+	return info, nil
+}
+
+// getUnbornBranch returns the full ref name of the branch HEAD points to in the repository containing dir, which
+// rev-parse cannot report while the branch has no commits.
+func getUnbornBranch(exec Executor, dir string) (string, error) {
+	cmdArr := []string{"git", "-C", dir, "symbolic-ref", "--quiet", "HEAD"}
+	out, err := runAndGetOutput(exec, cmdArr)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// resolvePath returns p, which rev-parse reported relative to dir, as a clean absolute path.
+func resolvePath(dir string, p string) string {
+	if filepath.IsAbs(p) {
+		return filepath.Clean(p)
+	}
+	return filepath.Join(dir, p)
+}
@@ -0,0 +1,124 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDiscoverRepository(t *testing.T) {
+	setup()
+	{ // A subdirectory of a submodule's working tree
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls,
+			fakeResponse{stdout: "git version 2.39.5\n"},
+			fakeResponse{stdout: "/src/super/.git/modules/sub\n/src/super/.git/modules/sub\nfalse\ntrue\nfalse\n" +
+				"true\ndir/\nsha256\n../\n/src/super\nrefs/heads/main\n"})
+		info, err := DiscoverRepository(mockGit, "/src/super/sub/dir")
+		if err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		expected := RepositoryInfo{
+			TopLevel:             "/src/super/sub",
+			GitDir:               "/src/super/.git/modules/sub",
+			CommonDir:            "/src/super/.git/modules/sub",
+			Shallow:              true,
+			InsideWorkTree:       true,
+			SuperprojectWorkTree: "/src/super",
+			ObjectFormat:         "sha256",
+			Prefix:               "dir/",
+			Head:                 HeadState{Branch: "refs/heads/main"},
+		}
+		if !reflect.DeepEqual(info, expected) {
+			t.Fatalf("Expected %+v, but received %+v", expected, info)
+		}
+		expectedCmd := []string{"git", "-C", "/src/super/sub/dir", "rev-parse", "--revs-only", "--absolute-git-dir",
+			"--git-common-dir", "--is-bare-repository", "--is-shallow-repository", "--is-inside-git-dir",
+			"--is-inside-work-tree", "--show-prefix", "--show-object-format", "--show-cdup",
+			"--show-superproject-working-tree", "--symbolic-full-name", "HEAD"}
+		if !reflect.DeepEqual(calls[1], expectedCmd) {
+			t.Fatalf("Expected %v, but received %v", expectedCmd, calls[1])
+		}
+	}
+	{ // The top level of a linked worktree with a detached HEAD
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls,
+			fakeResponse{stdout: "git version 2.39.5\n"},
+			fakeResponse{stdout: "/src/repo/.git/worktrees/wt\n/src/repo/.git\nfalse\nfalse\nfalse\ntrue\n\nsha1\n\n" +
+				"HEAD\n"})
+		info, err := DiscoverRepository(mockGit, "/src/wt")
+		if err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		expected := RepositoryInfo{
+			TopLevel:       "/src/wt",
+			GitDir:         "/src/repo/.git/worktrees/wt",
+			CommonDir:      "/src/repo/.git",
+			InsideWorkTree: true,
+			ObjectFormat:   "sha1",
+			Head:           HeadState{Detached: true},
+		}
+		if !reflect.DeepEqual(info, expected) {
+			t.Fatalf("Expected %+v, but received %+v", expected, info)
+		}
+	}
+	{ // A new bare repository, with git too old to report the object format
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls,
+			fakeResponse{stdout: "git version 2.25.1\n"},
+			fakeResponse{stdout: "/src/repo.git\n.\ntrue\nfalse\ntrue\nfalse\n\n"},
+			fakeResponse{stdout: "refs/heads/main\n"})
+		info, err := DiscoverRepository(mockGit, "/src/repo.git")
+		if err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		expected := RepositoryInfo{
+			GitDir:       "/src/repo.git",
+			CommonDir:    "/src/repo.git",
+			Bare:         true,
+			InsideGitDir: true,
+			ObjectFormat: "sha1",
+			Head:         HeadState{Branch: "refs/heads/main", Unborn: true},
+		}
+		if !reflect.DeepEqual(info, expected) {
+			t.Fatalf("Expected %+v, but received %+v", expected, info)
+		}
+		expectedCmd := []string{"git", "-C", "/src/repo.git", "symbolic-ref", "--quiet", "HEAD"}
+		if !reflect.DeepEqual(calls[2], expectedCmd) {
+			t.Fatalf("Expected %v, but received %v", expectedCmd, calls[2])
+		}
+	}
+	{ // Relative paths are made absolute.
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls,
+			fakeResponse{stdout: "git version 2.39.5\n"},
+			fakeResponse{exitStatus: 128})
+		DiscoverRepository(mockGit, "repo")
+		if !filepath.IsAbs(calls[1][2]) {
+			t.Fatalf("Expected an absolute path, but received %v", calls[1])
+		}
+	}
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls,
+			fakeResponse{stdout: "git version 2.39.5\n"},
+			fakeResponse{stderr: "fatal: not a git repository (or any of the parent directories): .git\n",
+				exitStatus: 128})
+		if _, err := DiscoverRepository(mockGit, "/tmp"); !errors.Is(err, ErrNotARepository) {
+			t.Fatalf("Expected ErrNotARepository, but received %v", err)
+		}
+	}
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls,
+			fakeResponse{stdout: "git version 2.39.5\n"},
+			fakeResponse{stdout: "/src/repo/.git\n"})
+		if _, err := DiscoverRepository(mockGit, "/src/repo"); err == nil {
+			t.Fatalf("Expected non-nil error")
+		}
+	}
+}
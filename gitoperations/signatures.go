@@ -0,0 +1,230 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnverifiedSignature is returned, wrapped, by VerifyCommit and VerifyTag when the object is not signed with a good
+// signature from a trusted key.
+var ErrUnverifiedSignature = errors.New("Signature not verified")
+
+// ErrAllowedSignersRequired is returned, wrapped, when SSH signatures cannot be verified because
+// gpg.ssh.allowedSignersFile is not configured.  Git would otherwise report those commits as unsigned.
+var ErrAllowedSignersRequired = errors.New("gpg.ssh.allowedSignersFile must be configured to verify SSH signatures")
+
+// SignatureStatus is the result of verifying a signature, as reported by git's %G? placeholder.
+type SignatureStatus string
+
+const (
+	// SignatureGood is a good signature made with a trusted key.
+	SignatureGood SignatureStatus = "good"
+	// SignatureUntrusted is a good signature made with a key of unknown validity, such as an SSH key which is not in
+	// the allowed signers file.
+	SignatureUntrusted SignatureStatus = "untrusted"
+	// SignatureExpired is a good signature which has expired.
+	SignatureExpired SignatureStatus = "expired"
+	// SignatureExpiredKey is a good signature made with a key which has since expired.
+	SignatureExpiredKey SignatureStatus = "expired-key"
+	// SignatureRevokedKey is a good signature made with a key which has since been revoked.
+	SignatureRevokedKey SignatureStatus = "revoked-key"
+	// SignatureBad is a signature which does not match the object, which was probably altered after signing.
+	SignatureBad SignatureStatus = "bad"
+	// SignatureMissingKey is a signature which cannot be checked, usually because the key is not available.
+	SignatureMissingKey SignatureStatus = "missing-key"
+	// SignatureNone means the object is not signed.
+	SignatureNone SignatureStatus = "unsigned"
+)
+
+var signatureStatuses = map[string]SignatureStatus{
+	"G": SignatureGood,
+	"U": SignatureUntrusted,
+	"X": SignatureExpired,
+	"Y": SignatureExpiredKey,
+	"R": SignatureRevokedKey,
+	"B": SignatureBad,
+	"E": SignatureMissingKey,
+	"N": SignatureNone,
+}
+
+// Signature describes a GPG, SSH or X.509 signature.  Signer is the signer's name and email for GPG, the principal for
+// SSH and the certificate subject for X.509.  Key is the id of the signing key, and Fingerprint and
+// PrimaryKeyFingerprint the fingerprints of the key and, for GPG subkeys, its primary key; SSH keys are identified by
+// their SHA256 fingerprint.  TrustLevel is the trust in the key, one of "undefined", "never", "marginal", "fully" and
+// "ultimate", and is only reported for signatures which could be checked, by git 2.26 or newer.
+type Signature struct {
+	Status                SignatureStatus
+	Signer                string
+	Key                   string
+	Fingerprint           string
+	PrimaryKeyFingerprint string
+	TrustLevel            string
+}
+
+// Valid reports whether the signature is good and made with a trusted key.
+func (signature Signature) Valid() bool {
+	return signature.Status == SignatureGood
+}
+
+// checked reports whether the signature could be verified, whatever the verdict, which is when git knows the trust
+// level of the key.
+func (signature Signature) checked() bool {
+	switch signature.Status {
+	case SignatureGood, SignatureUntrusted, SignatureExpired, SignatureExpiredKey, SignatureRevokedKey:
+		return true
+	}
+	return false
+}
+
+// CommitSignature is the signature of a commit.
+type CommitSignature struct {
+	Commit    ObjectID
+	Signature Signature
+}
+
+// The minimum git version which supports the %GT placeholder.
+const signatureTrustLevelMajor, signatureTrustLevelMinor = 2, 26
+
+func VerifyCommit(exec Executor, revision string) (CommitSignature, error) {
+	// Verifies the signature of the commit revision names.  A commit which is not signed with a good signature from a
+	// trusted key results in an error matching ErrUnverifiedSignature along with the signature's details.
+	if err := checkArguments(revision); err != nil {
+		return CommitSignature{}, err
+	}
+	signatures, err := readCommitSignatures(exec, "--no-walk", "--end-of-options", revision+"^{commit}")
+	if err != nil {
+		return CommitSignature{}, err
+	}
+	if len(signatures) != 1 {
+		return CommitSignature{}, fmt.Errorf("Expected one commit for %q, but found %d", revision, len(signatures))
+	}
+	if !signatures[0].Signature.Valid() {
+		return signatures[0], fmt.Errorf("%w: %s: %s", ErrUnverifiedSignature, signatures[0].Commit,
+			signatures[0].Signature.Status)
+	}
+	return signatures[0], nil
+}
+
+func VerifyRange(exec Executor, base string, head string) ([]CommitSignature, error) {
+	// Verifies every commit reachable from head but not from base, including merges, and returns those which are not
+	// signed with a good signature from a trusted key, newest first.  An empty result means every commit is signed.
+	// When base is empty every commit reachable from head is verified.
+	if err := checkArguments(base, head); err != nil {
+		return nil, err
+	}
+	revisions := head
+	if len(base) != 0 {
+		revisions = base + ".." + head
+	}
+	signatures, err := readCommitSignatures(exec, "--end-of-options", revisions)
+	if err != nil {
+		return nil, err
+	}
+	unverified := []CommitSignature{}
+	for _, signature := range signatures {
+		if !signature.Signature.Valid() {
+			unverified = append(unverified, signature)
+		}
+	}
+	return unverified, nil
+}
+
+// readCommitSignatures verifies the signatures of the commits git log lists with args.
+func readCommitSignatures(exec Executor, args ...string) ([]CommitSignature, error) {
+	// With -z each commit is reported as NUL terminated fields:
+	// <commit> NUL <%G?> NUL <signer> NUL <key> NUL <fingerprint> NUL <primary key fingerprint> NUL
+	// The trust levels are read separately, for only the signatures which could be checked, since some versions of
+	// git abort when asked for the trust level of other signatures.
+	cmdArr := append([]string{"git", "log", "-z", "--format=%H%x00%G?%x00%GS%x00%GK%x00%GF%x00%GP"}, args...)
+	out, stderr, err := runAndGetSeparateOutputs(exec, cmdArr, nil)
+	if err != nil {
+		return nil, err
+	}
+	if strings.Contains(string(stderr), "allowedSignersFile needs to be configured") {
+		return nil, fmt.Errorf("%w: %s", ErrAllowedSignersRequired, strings.TrimSpace(string(stderr)))
+	}
+	records := splitNul(out)
+	if len(records)%6 != 0 {
+		return nil, errors.New("Unrecognized log output: " + string(out))
+	}
+	signatures := []CommitSignature{}
+	checked := []string{}
+	for i := 0; i < len(records); i += 6 {
+		commit, err := ParseObjectID(records[i])
+		if err != nil {
+			return nil, err
+		}
+		status, ok := signatureStatuses[records[i+1]]
+		if !ok {
+			return nil, errors.New("Unrecognized signature status: " + records[i+1])
+		}
+		signature := Signature{Status: status, Signer: records[i+2], Key: records[i+3], Fingerprint: records[i+4],
+			PrimaryKeyFingerprint: records[i+5]}
+		signatures = append(signatures, CommitSignature{Commit: commit, Signature: signature})
+		if signature.checked() {
+			checked = append(checked, records[i])
+		}
+	}
+	if len(checked) == 0 {
+		return signatures, nil
+	}
+	trustLevels, err := readTrustLevels(exec, checked)
+	if err != nil {
+		return nil, err
+	}
+	for i := range signatures {
+		signatures[i].Signature.TrustLevel = trustLevels[signatures[i].Commit]
+	}
+	return signatures, nil
+}
+
+// readTrustLevels returns the trust levels of the keys which signed commits, which is empty with git older than 2.26.
+func readTrustLevels(exec Executor, commits []string) (map[ObjectID]string, error) {
+	// The commits are passed on stdin, and each is reported as:
+	// <commit> NUL <trust level> NUL
+	trustLevels := map[ObjectID]string{}
+	version, err := GetGitVersion(exec)
+	if err != nil {
+		return nil, err
+	}
+	if !version.AtLeast(signatureTrustLevelMajor, signatureTrustLevelMinor) {
+		return trustLevels, nil
+	}
+	cmdArr := []string{"git", "log", "-z", "--no-walk=unsorted", "--format=%H%x00%GT", "--stdin"}
+	out, err := runWithInputAndGetOutput(exec, cmdArr, []byte(strings.Join(commits, "\n")+"\n"))
+	if err != nil {
+		return nil, err
+	}
+	records := splitNul(out)
+	if len(records)%2 != 0 {
+		return nil, errors.New("Unrecognized log output: " + string(out))
+	}
+	for i := 0; i < len(records); i += 2 {
+		trustLevels[ObjectID(records[i])] = records[i+1]
+	}
+	return trustLevels, nil
+}
+
+func VerifyTag(exec Executor, tag string) error {
+	// Verifies the signature of an annotated tag.  Tags which are not signed with a good signature, including
+	// lightweight tags, result in an error matching ErrUnverifiedSignature.
+	if err := checkArguments(tag); err != nil {
+		return err
+	}
+	cmdArr := []string{"git", "verify-tag", "--end-of-options", tag}
+	if _, err := runAndGetOutput(exec, cmdArr); err != nil {
+		var gitErr *GitError
+		if !errors.As(err, &gitErr) || strings.Contains(gitErr.Stderr, "not found") {
+			return err
+		}
+		if strings.Contains(gitErr.Stderr, "allowedSignersFile needs to be configured") {
+			return fmt.Errorf("%w: %v", ErrAllowedSignersRequired, err)
+		}
+		return fmt.Errorf("%w: %q: %v", ErrUnverifiedSignature, tag, err)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestVerifyCommit(t *testing.T) {
+	setup()
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls,
+			fakeResponse{stdout: testSHA1 + "\x00G\x00Tester <a@b>\x00258DC86B59660B7E\x00" +
+				"38C67BC393AA449E9AEEC0DC258DC86B59660B7E\x0038C67BC393AA449E9AEEC0DC258DC86B59660B7E\x00"},
+			fakeResponse{stdout: "git version 2.39.5\n"},
+			fakeResponse{stdout: testSHA1 + "\x00ultimate\x00"})
+		signature, err := VerifyCommit(mockGit, "main")
+		if err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		expected := CommitSignature{Commit: testSHA1, Signature: Signature{Status: SignatureGood,
+			Signer: "Tester <a@b>", Key: "258DC86B59660B7E", Fingerprint: "38C67BC393AA449E9AEEC0DC258DC86B59660B7E",
+			PrimaryKeyFingerprint: "38C67BC393AA449E9AEEC0DC258DC86B59660B7E", TrustLevel: "ultimate"}}
+		if !reflect.DeepEqual(signature, expected) {
+			t.Fatalf("Expected %+v, but received %+v", expected, signature)
+		}
+		expectedCmds := [][]string{
+			{"git", "log", "-z", "--format=%H%x00%G?%x00%GS%x00%GK%x00%GF%x00%GP", "--no-walk", "--end-of-options",
+				"main^{commit}"},
+			{"git", "version"},
+			{"git", "log", "-z", "--no-walk=unsorted", "--format=%H%x00%GT", "--stdin"},
+		}
+		if !reflect.DeepEqual(calls, expectedCmds) {
+			t.Fatalf("Expected %v, but received %v", expectedCmds, calls)
+		}
+	}
+	{ // Bad signatures are reported without their trust level, which some versions of git fail to report.
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls,
+			fakeResponse{stdout: testSHA1 + "\x00B\x00Tester <a@b>\x00258DC86B59660B7E\x00\x00\x00"})
+		signature, err := VerifyCommit(mockGit, "HEAD")
+		if !errors.Is(err, ErrUnverifiedSignature) || signature.Signature.Status != SignatureBad {
+			t.Fatalf("Expected a bad signature and ErrUnverifiedSignature, but received %+v, %v", signature, err)
+		}
+		if len(calls) != 1 {
+			t.Fatalf("Expected only the signature to be read, but received %v", calls)
+		}
+	}
+	{ // Without an allowed signers file git reports SSH signed commits as unsigned.
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls, fakeResponse{stdout: testSHA1 + "\x00N\x00\x00\x00\x00\x00",
+			stderr: "error: gpg.ssh.allowedSignersFile needs to be configured and exist for ssh signature " +
+				"verification\n"})
+		if _, err := VerifyCommit(mockGit, "HEAD"); !errors.Is(err, ErrAllowedSignersRequired) {
+			t.Fatalf("Expected ErrAllowedSignersRequired, but received %v", err)
+		}
+	}
+	{
+		calls := [][]string{}
+		if _, err := VerifyCommit(createScriptedExecCommand(&calls, fakeResponse{}), "--all"); !errors.Is(err,
+			ErrUnsafeArgument) {
+			t.Fatalf("Expected ErrUnsafeArgument, but received %v", err)
+		}
+	}
+}
+
+func TestVerifyRange(t *testing.T) {
+	setup()
+	calls := [][]string{}
+	mockGit := createScriptedExecCommand(&calls,
+		fakeResponse{stdout: "" +
+			"72ab9836d99c32170fbd59507189ab2b053997c2\x00G\x00Tester <a@b>\x00K1\x00F1\x00F1\x00" +
+			"fe15b9ba1b3f14a3787ad10d762e14d7b590dcf3\x00U\x00\x00SHA256:XR3y\x00SHA256:XR3y\x00\x00" +
+			"4382051f5a7f4b61a86b761ac3bac5618f98021e\x00N\x00\x00\x00\x00\x00"},
+		fakeResponse{stdout: "git version 2.25.1\n"})
+	unverified, err := VerifyRange(mockGit, "origin/main", "HEAD")
+	if err != nil {
+		t.Fatalf("Expected nil error, but received %v", err)
+	}
+	expected := []CommitSignature{
+		{Commit: "fe15b9ba1b3f14a3787ad10d762e14d7b590dcf3", Signature: Signature{Status: SignatureUntrusted,
+			Key: "SHA256:XR3y", Fingerprint: "SHA256:XR3y"}},
+		{Commit: "4382051f5a7f4b61a86b761ac3bac5618f98021e", Signature: Signature{Status: SignatureNone}},
+	}
+	if !reflect.DeepEqual(unverified, expected) {
+		t.Fatalf("Expected %+v, but received %+v", expected, unverified)
+	}
+	// Git older than 2.26 cannot report trust levels.
+	if len(calls) != 2 || calls[0][len(calls[0])-1] != "origin/main..HEAD" {
+		t.Fatalf("Unexpected commands %v", calls)
+	}
+
+	mockGit = createScriptedExecCommand(&calls, fakeResponse{stdout: testSHA1 + "\x00?\x00\x00\x00\x00\x00"})
+	if _, err := VerifyRange(mockGit, "", "HEAD"); err == nil {
+		t.Fatalf("Expected non-nil error")
+	}
+}
+
+func TestVerifyTag(t *testing.T) {
+	setup()
+	calls := [][]string{}
+	if err := VerifyTag(createScriptedExecCommand(&calls, fakeResponse{}), "v1.0"); err != nil {
+		t.Fatalf("Expected nil error, but received %v", err)
+	}
+	expectedCmd := []string{"git", "verify-tag", "--end-of-options", "v1.0"}
+	if !reflect.DeepEqual(calls[0], expectedCmd) {
+		t.Fatalf("Expected %v, but received %v", expectedCmd, calls[0])
+	}
+
+	mockGit := createScriptedExecCommand(&calls,
+		fakeResponse{stderr: "error: light: cannot verify a non-tag object of type commit.\n", exitStatus: 1})
+	if err := VerifyTag(mockGit, "light"); !errors.Is(err, ErrUnverifiedSignature) {
+		t.Fatalf("Expected ErrUnverifiedSignature, but received %v", err)
+	}
+	mockGit = createScriptedExecCommand(&calls, fakeResponse{stderr: "error: tag 'nope' not found.\n", exitStatus: 1})
+	if err := VerifyTag(mockGit, "nope"); err == nil || errors.Is(err, ErrUnverifiedSignature) {
+		t.Fatalf("Expected a plain error, but received %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// ErrSigningFailed is returned, wrapped, when git cannot sign a commit or tag, for example because the key is not
+// available or its passphrase cannot be prompted for.  The error includes what the signing program reported.
+var ErrSigningFailed = errors.New("Signing failed")
+
+// SignatureFormat is the kind of signature git creates, as configured by gpg.format.
+type SignatureFormat string
+
+const (
+	SignatureFormatOpenPGP SignatureFormat = "openpgp"
+	SignatureFormatSSH     SignatureFormat = "ssh"
+	SignatureFormatX509    SignatureFormat = "x509"
+)
+
+// SignMode selects whether a commit or tag is signed.
+type SignMode int
+
+const (
+	// SignDefault signs as configured: commits and merges when commit.gpgSign is set and annotated tags when
+	// tag.gpgSign is set.
+	SignDefault SignMode = iota
+	SignAlways
+	SignNever
+)
+
+// SigningOptions controls how commits and tags are signed.  The zero value follows the user's configuration, signing
+// with user.signingKey in the format of gpg.format.
+type SigningOptions struct {
+	Mode SignMode
+	// KeyID overrides user.signingKey.  For OpenPGP and X.509 it is a key id or fingerprint, and for SSH the path to
+	// a key, or a public key prefixed with "key::".
+	KeyID string
+	// Format overrides gpg.format.
+	Format SignatureFormat
+	// Program overrides the program which signs in Format, such as gpg, gpgsm or ssh-keygen.  When Format is empty it
+	// overrides the OpenPGP program.
+	Program string
+}
+
+// configArgs returns the -c options which apply the signing settings to git.
+func (opts SigningOptions) configArgs() []string {
+	args := []string{}
+	if len(opts.KeyID) != 0 {
+		args = append(args, "-c", "user.signingKey="+opts.KeyID)
+	}
+	if len(opts.Format) != 0 {
+		args = append(args, "-c", "gpg.format="+string(opts.Format))
+	}
+	if len(opts.Program) != 0 {
+		key := "gpg.program"
+		if opts.Format == SignatureFormatSSH || opts.Format == SignatureFormatX509 {
+			key = "gpg." + string(opts.Format) + ".program"
+		}
+		args = append(args, "-c", key+"="+opts.Program)
+	}
+	return args
+}
+
+// commitArgs returns the option selecting whether commit, merge and commit-tree sign.
+func (opts SigningOptions) commitArgs() []string {
+	switch opts.Mode {
+	case SignAlways:
+		return []string{"--gpg-sign"}
+	case SignNever:
+		return []string{"--no-gpg-sign"}
+	}
+	return nil
+}
+
+var reForSigningFailure = regexp.MustCompile(
+	`failed to sign|unable to sign|signing failed|failed to write commit object|incorrect passphrase`)
+
+// signingError reports failures of the signing program as ErrSigningFailed.
+func signingError(err error) error {
+	var gitErr *GitError
+	if errors.As(err, &gitErr) && reForSigningFailure.MatchString(gitErr.Stderr) {
+		return fmt.Errorf("%w: %v", ErrSigningFailed, err)
+	}
+	return err
+}
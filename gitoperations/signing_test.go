@@ -0,0 +1,48 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSigningOptionsArgs(t *testing.T) {
+	if args := (SigningOptions{}).configArgs(); len(args) != 0 {
+		t.Fatalf("Expected no config overrides, but received %v", args)
+	}
+	if args := (SigningOptions{}).commitArgs(); len(args) != 0 {
+		t.Fatalf("Expected no signing option, but received %v", args)
+	}
+	opts := SigningOptions{Mode: SignAlways, KeyID: "key::ssh-ed25519 AAAA", Format: SignatureFormatSSH,
+		Program: "/opt/ssh-keygen"}
+	expected := []string{"-c", "user.signingKey=key::ssh-ed25519 AAAA", "-c", "gpg.format=ssh", "-c",
+		"gpg.ssh.program=/opt/ssh-keygen"}
+	if args := opts.configArgs(); !reflect.DeepEqual(args, expected) {
+		t.Fatalf("Expected %v, but received %v", expected, args)
+	}
+	if args := opts.commitArgs(); !reflect.DeepEqual(args, []string{"--gpg-sign"}) {
+		t.Fatalf("Expected --gpg-sign, but received %v", args)
+	}
+	opts = SigningOptions{Mode: SignNever, Program: "/usr/bin/gpg2"}
+	if args := opts.configArgs(); !reflect.DeepEqual(args, []string{"-c", "gpg.program=/usr/bin/gpg2"}) {
+		t.Fatalf("Expected gpg.program, but received %v", args)
+	}
+	if args := opts.commitArgs(); !reflect.DeepEqual(args, []string{"--no-gpg-sign"}) {
+		t.Fatalf("Expected --no-gpg-sign, but received %v", args)
+	}
+}
+
+func TestSigningError(t *testing.T) {
+	err := signingError(&GitError{ExitCode: 128,
+		Stderr: "error: gpg failed to sign the data\nfatal: failed to write commit object\n"})
+	if !errors.Is(err, ErrSigningFailed) {
+		t.Fatalf("Expected ErrSigningFailed, but received %v", err)
+	}
+	err = &GitError{ExitCode: 1, Stderr: "error: pathspec 'x' did not match any file(s) known to git\n"}
+	if signingError(err) != err {
+		t.Fatalf("Expected the error to be returned unchanged")
+	}
+}
@@ -0,0 +1,283 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrPreflightFailed is returned, wrapped, when RunSquashMergeWorkflow finds the repository in a state it cannot start
+// from.  Nothing but the remote-tracking branch of the target branch, which is fetched to check against, has been
+// changed when it is returned.
+var ErrPreflightFailed = errors.New("Squash merge preflight check failed")
+
+// SquashMergeWorkflow describes squashing a feature branch into a single commit on a target branch, such as mainline,
+// and pushing the target branch.
+type SquashMergeWorkflow struct {
+	// Source is the branch to squash, or the checked out branch when empty.
+	Source string
+	// Target is the branch to squash into.
+	Target string
+	// Remote is the remote Target is fetched from and pushed to, "origin" when empty.
+	Remote string
+	// Message is the squashed commit's message.  When empty it is generated from Source's commits.
+	Message string
+	// Author overrides the author of the squashed commit, given as "Name <email>".
+	Author string
+	// DeleteSource deletes the local Source branch once Target has been pushed.
+	DeleteSource bool
+	Signing      SigningOptions
+}
+
+// squashMergeState records what RunSquashMergeWorkflow started from, for rolling back.
+type squashMergeState struct {
+	gitDir string
+	// head is the full name of the checked out branch, or empty when HEAD is detached at headCommit.
+	head       string
+	headCommit ObjectID
+	source     ObjectID
+	// target is empty when the local target branch does not exist.
+	target ObjectID
+}
+
+// squashMergeInProgress lists the files, relative to the git directory, whose presence shows an operation is in
+// progress.
+var squashMergeInProgress = []string{"MERGE_HEAD", "rebase-merge", "rebase-apply", "CHERRY_PICK_HEAD", "REVERT_HEAD",
+	"BISECT_LOG"}
+
+func RunSquashMergeWorkflow(exec Executor, workflow SquashMergeWorkflow) (ObjectID, error) {
+	// Returns the id of the squashed commit, which is left checked out on the target branch.  The steps are:
+	// 1. Preflight checks: the working tree has no changes to tracked files, no merge, rebase, cherry-pick, revert or
+	//    bisect is in progress, <remote>/<target> exists once fetched, the local target branch has nothing which is
+	//    not on the remote, the source branch is not behind its own upstream and has commits to squash.
+	// 2. Rebase the source branch onto <remote>/<target>, so that conflicts surface against the latest target.
+	// 3. Reset the target branch to <remote>/<target>, squash the source branch into it and commit.
+	// 4. Push the target branch, after which nothing is rolled back, then delete the source branch if asked.
+	// A failure before the push restores the source and target branches and checks out the original branch and HEAD.
+	if len(workflow.Remote) == 0 {
+		workflow.Remote = "origin"
+	}
+	state, err := squashMergePreflight(exec, &workflow)
+	if err != nil {
+		return "", err
+	}
+	commit, err := squashMerge(exec, workflow)
+	if err != nil {
+		if rollbackErr := squashMergeRollback(exec, workflow, state); rollbackErr != nil {
+			return "", fmt.Errorf("%w (rolling back also failed: %v)", err, rollbackErr)
+		}
+		return "", err
+	}
+	if workflow.DeleteSource {
+		cmdArr := []string{"git", "branch", "--quiet", "-D", "--", workflow.Source}
+		if _, err := runAndGetOutput(exec, cmdArr); err != nil {
+			return commit, fmt.Errorf("%s was pushed, but deleting %s failed: %w", workflow.Target, workflow.Source, err)
+		}
+	}
+	return commit, nil
+}
+
+// squashMergePreflight checks that the workflow can start, filling in the source branch when it is empty, and returns
+// the state to roll back to.
+func squashMergePreflight(exec Executor, workflow *SquashMergeWorkflow) (squashMergeState, error) {
+	// The repository is found through exec, which may run git in another directory than this process's.
+	cmdArr := []string{"git", "rev-parse", "--absolute-git-dir", "--is-inside-work-tree"}
+	out, err := runAndGetOutput(exec, cmdArr)
+	if err != nil {
+		return squashMergeState{}, err
+	}
+	lines := strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
+	if len(lines) != 2 {
+		return squashMergeState{}, errors.New("Unrecognized rev-parse output: " + string(out))
+	}
+	if lines[1] != "true" {
+		return squashMergeState{}, fmt.Errorf("%w: a working tree is required", ErrPreflightFailed)
+	}
+	state := squashMergeState{gitDir: lines[0]}
+	// symbolic-ref exits 1 when HEAD is detached.
+	cmdArr = []string{"git", "symbolic-ref", "--quiet", "HEAD"}
+	out, err = runAndGetOutput(exec, cmdArr)
+	if err != nil && exitCodeOf(err) != 1 {
+		return squashMergeState{}, err
+	}
+	state.head = strings.TrimSpace(string(out))
+	if len(workflow.Source) == 0 {
+		if len(state.head) == 0 {
+			return squashMergeState{}, fmt.Errorf("%w: HEAD is detached and no source branch was given",
+				ErrPreflightFailed)
+		}
+		workflow.Source = strings.TrimPrefix(state.head, "refs/heads/")
+	}
+	if err := checkArguments(workflow.Source, workflow.Target, workflow.Remote); err != nil {
+		return squashMergeState{}, err
+	}
+	if len(workflow.Target) == 0 || workflow.Source == workflow.Target {
+		return squashMergeState{}, fmt.Errorf("%w: the target branch must be given and differ from %q",
+			ErrPreflightFailed, workflow.Source)
+	}
+	for _, name := range squashMergeInProgress {
+		if _, err := os.Stat(filepath.Join(state.gitDir, name)); err == nil {
+			return squashMergeState{}, fmt.Errorf("%w: an operation is in progress (%s exists)", ErrPreflightFailed,
+				name)
+		}
+	}
+	cmdArr = []string{"git", "status", "--porcelain", "-z", "--untracked-files=no"}
+	out, err = runAndGetOutput(exec, cmdArr)
+	if err != nil {
+		return squashMergeState{}, err
+	}
+	if len(out) != 0 {
+		return squashMergeState{}, fmt.Errorf("%w: the working tree has uncommitted changes", ErrPreflightFailed)
+	}
+
+	if state.headCommit, err = resolveRef(exec, "HEAD"); err != nil {
+		return squashMergeState{}, err
+	}
+	if len(state.headCommit) == 0 {
+		return squashMergeState{}, fmt.Errorf("%w: the current branch has no commits", ErrPreflightFailed)
+	}
+	if state.source, err = resolveRef(exec, "refs/heads/"+workflow.Source); err != nil {
+		return squashMergeState{}, err
+	}
+	if len(state.source) == 0 {
+		return squashMergeState{}, fmt.Errorf("%w: branch %q does not exist", ErrPreflightFailed, workflow.Source)
+	}
+	if state.target, err = resolveRef(exec, "refs/heads/"+workflow.Target); err != nil {
+		return squashMergeState{}, err
+	}
+
+	upstream := squashMergeUpstream(*workflow)
+	refspec := "+refs/heads/" + workflow.Target + ":" + upstream
+	if _, err := FetchRemote(exec, workflow.Remote, []string{refspec}, FetchOptions{}); err != nil {
+		return squashMergeState{}, err
+	}
+	if id, err := resolveRef(exec, upstream); err != nil || len(id) == 0 {
+		return squashMergeState{}, fmt.Errorf("%w: %s does not exist", ErrPreflightFailed, upstream)
+	}
+	if len(state.target) != 0 {
+		upToDate, err := IsAncestor(exec, string(state.target), upstream)
+		if err != nil {
+			return squashMergeState{}, err
+		}
+		if !upToDate {
+			return squashMergeState{}, fmt.Errorf("%w: %s has commits which are not on %s", ErrPreflightFailed,
+				workflow.Target, upstream)
+		}
+	}
+	counts, err := AheadBehind(exec, "", "refs/heads/"+workflow.Source)
+	if err != nil {
+		return squashMergeState{}, err
+	}
+	if len(counts) == 1 && counts[0].Status == AheadBehindCounted && counts[0].Behind != 0 {
+		return squashMergeState{}, fmt.Errorf("%w: %s is %d commits behind %s", ErrPreflightFailed, workflow.Source,
+			counts[0].Behind, counts[0].Base)
+	}
+	merged, err := IsAncestor(exec, string(state.source), upstream)
+	if err != nil {
+		return squashMergeState{}, err
+	}
+	if merged {
+		return squashMergeState{}, fmt.Errorf("%w: %s has no commits which are not on %s", ErrPreflightFailed,
+			workflow.Source, upstream)
+	}
+	return state, nil
+}
+
+// squashMerge performs the steps of the workflow up to and including the push.
+func squashMerge(exec Executor, workflow SquashMergeWorkflow) (ObjectID, error) {
+	upstream := squashMergeUpstream(workflow)
+	cmdArr := []string{"git", "rebase", "--quiet", "--end-of-options", upstream, workflow.Source}
+	if _, err := runAndGetOutput(exec, cmdArr); err != nil {
+		return "", err
+	}
+	message := workflow.Message
+	if len(message) == 0 {
+		var err error
+		if message, err = squashMergeMessage(exec, workflow); err != nil {
+			return "", err
+		}
+	}
+	cmdArr = []string{"git", "checkout", "--quiet", "-B", workflow.Target, upstream, "--"}
+	if _, err := runAndGetOutput(exec, cmdArr); err != nil {
+		return "", err
+	}
+	if _, err := Merge(exec, []string{workflow.Source}, MergeOptions{Squash: true}); err != nil {
+		return "", err
+	}
+	commit, err := CreateCommit(exec, CommitOptions{Message: message, Author: workflow.Author,
+		Signing: workflow.Signing})
+	if err != nil {
+		return "", err
+	}
+	refspec := "refs/heads/" + workflow.Target + ":refs/heads/" + workflow.Target
+	if _, err := PushRemote(exec, workflow.Remote, []string{refspec}, PushOptions{}); err != nil {
+		return "", err
+	}
+	return commit, nil
+}
+
+// squashMergeMessage generates the squashed commit's message.  A single commit keeps its message, and several are
+// summarized by their subjects, oldest first.
+func squashMergeMessage(exec Executor, workflow SquashMergeWorkflow) (string, error) {
+	cmdArr := []string{"git", "log", "-z", "--reverse", "--format=%B", "--end-of-options",
+		squashMergeUpstream(workflow) + ".." + workflow.Source}
+	out, err := runAndGetOutput(exec, cmdArr)
+	if err != nil {
+		return "", err
+	}
+	messages := splitNul(out)
+	if len(messages) == 1 {
+		return messages[0], nil
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Squash merge branch '%s' into %s\n\n", workflow.Source, workflow.Target)
+	for _, message := range messages {
+		fmt.Fprintf(&sb, "* %s\n", strings.SplitN(message, "\n", 2)[0])
+	}
+	return sb.String(), nil
+}
+
+// squashMergeRollback restores the branches and HEAD recorded in state, abandoning any rebase or squash in progress.
+// The message of an abandoned squash is removed, so that the user's next commit does not pick it up.
+func squashMergeRollback(exec Executor, workflow SquashMergeWorkflow, state squashMergeState) error {
+	for _, name := range []string{"rebase-merge", "rebase-apply"} {
+		if _, err := os.Stat(filepath.Join(state.gitDir, name)); err == nil {
+			if _, err := runAndGetOutput(exec, []string{"git", "rebase", "--abort"}); err != nil {
+				return err
+			}
+			break
+		}
+	}
+	cmdArr := []string{"git", "update-ref", "refs/heads/" + workflow.Source, string(state.source)}
+	if _, err := runAndGetOutput(exec, cmdArr); err != nil {
+		return err
+	}
+	cmdArr = []string{"git", "update-ref", "-d", "refs/heads/" + workflow.Target}
+	if len(state.target) != 0 {
+		cmdArr = []string{"git", "update-ref", "refs/heads/" + workflow.Target, string(state.target)}
+	}
+	if _, err := runAndGetOutput(exec, cmdArr); err != nil {
+		return err
+	}
+	cmdArr = []string{"git", "checkout", "--quiet", "--force", "--detach", string(state.headCommit), "--"}
+	if len(state.head) != 0 {
+		cmdArr = []string{"git", "checkout", "--quiet", "--force", strings.TrimPrefix(state.head, "refs/heads/"), "--"}
+	}
+	if _, err := runAndGetOutput(exec, cmdArr); err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(state.gitDir, "SQUASH_MSG")); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// squashMergeUpstream returns the remote-tracking ref of the workflow's target branch.
+func squashMergeUpstream(workflow SquashMergeWorkflow) string {
+	return "refs/remotes/" + workflow.Remote + "/" + workflow.Target
+}
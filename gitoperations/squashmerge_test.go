@@ -0,0 +1,259 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// squashMergePreflightResponses script the preflight checks of a workflow squashing feat into a main branch which only
+// exists on the remote.
+func squashMergePreflightResponses(gitDir string) []fakeResponse {
+	return []fakeResponse{
+		{stdout: gitDir + "\ntrue\n"},
+		{stdout: "refs/heads/feat\n"},
+		{},
+		{stdout: testSHA1 + "\n"},
+		{stdout: testSHA1 + "\n"},
+		{exitStatus: 1},
+		{stdout: "git version 2.39.5\n"},
+		{},
+		{stdout: "bf3bd89e9a0a3c2ba7e8cf4e1a3bfc1d8bc35ff1\n"},
+		{stdout: "refs/heads/feat\x00\x00\n"},
+		{exitStatus: 1},
+	}
+}
+
+var squashMergePreflightCmds = [][]string{
+	{"git", "rev-parse", "--absolute-git-dir", "--is-inside-work-tree"},
+	{"git", "symbolic-ref", "--quiet", "HEAD"},
+	{"git", "status", "--porcelain", "-z", "--untracked-files=no"},
+	{"git", "rev-parse", "--quiet", "--verify", "--end-of-options", "HEAD"},
+	{"git", "rev-parse", "--quiet", "--verify", "--end-of-options", "refs/heads/feat"},
+	{"git", "rev-parse", "--quiet", "--verify", "--end-of-options", "refs/heads/main"},
+	{"git", "version"},
+	{"git", "fetch", "--verbose", "--", "origin", "+refs/heads/main:refs/remotes/origin/main"},
+	{"git", "rev-parse", "--quiet", "--verify", "--end-of-options", "refs/remotes/origin/main"},
+	{"git", "for-each-ref", "--format=%(refname)%00%(upstream)%00%(upstream:track,nobracket)", "--",
+		"refs/heads/feat"},
+	{"git", "merge-base", "--is-ancestor", "--end-of-options", testSHA1, "refs/remotes/origin/main"},
+}
+
+func TestRunSquashMergeWorkflow(t *testing.T) {
+	setup()
+	gitDir, err := ioutil.TempDir("", "squashmerge")
+	if err != nil {
+		t.Fatalf("Expected nil error, but received %v", err)
+	}
+	defer os.RemoveAll(gitDir)
+	{
+		calls := [][]string{}
+		responses := append(squashMergePreflightResponses("/src/repo/.git"),
+			fakeResponse{},
+			fakeResponse{stdout: "Add a\n\nDetails\n\x00Add b\n\x00"},
+			fakeResponse{},
+			fakeResponse{},
+			fakeResponse{},
+			fakeResponse{stdout: testSHA1 + "\n"},
+			fakeResponse{stdout: "To /src/remote.git\n \trefs/heads/main:refs/heads/main\t1111111..2222222\nDone\n"},
+			fakeResponse{})
+		commit, err := RunSquashMergeWorkflow(createScriptedExecCommand(&calls, responses...),
+			SquashMergeWorkflow{Target: "main", DeleteSource: true})
+		if err != nil || commit != testSHA1 {
+			t.Fatalf("Expected %s, but received %q, %v", testSHA1, commit, err)
+		}
+		expectedCmds := append(append([][]string{}, squashMergePreflightCmds...),
+			[]string{"git", "rebase", "--quiet", "--end-of-options", "refs/remotes/origin/main", "feat"},
+			[]string{"git", "log", "-z", "--reverse", "--format=%B", "--end-of-options", "refs/remotes/origin/main..feat"},
+			[]string{"git", "checkout", "--quiet", "-B", "main", "refs/remotes/origin/main", "--"},
+			[]string{"git", "merge", "--quiet", "--no-edit", "--squash", "--end-of-options", "feat"},
+			[]string{"git", "commit", "--quiet", "--file=-"},
+			[]string{"git", "rev-parse", "--verify", "--end-of-options", "HEAD^{commit}"},
+			[]string{"git", "push", "--porcelain", "--", "origin", "refs/heads/main:refs/heads/main"},
+			[]string{"git", "branch", "--quiet", "-D", "--", "feat"})
+		if !reflect.DeepEqual(calls, expectedCmds) {
+			t.Fatalf("Expected %v, but received %v", expectedCmds, calls)
+		}
+		message, err := squashMergeMessage(createScriptedExecCommand(&calls,
+			fakeResponse{stdout: "Add a\n\nDetails\n\x00Add b\n\x00"}), SquashMergeWorkflow{Source: "feat", Target: "main",
+			Remote: "origin"})
+		if expected := "Squash merge branch 'feat' into main\n\n* Add a\n* Add b\n"; err != nil || message != expected {
+			t.Fatalf("Expected %q, but received %q, %v", expected, message, err)
+		}
+	}
+	{ // A rejected push rolls back the branches and HEAD, and removes the squash message.
+		calls := [][]string{}
+		if err := ioutil.WriteFile(filepath.Join(gitDir, "SQUASH_MSG"), []byte("Squashed\n"), 0600); err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		responses := append(squashMergePreflightResponses(gitDir),
+			fakeResponse{},
+			fakeResponse{},
+			fakeResponse{},
+			fakeResponse{},
+			fakeResponse{stdout: testSHA1 + "\n"},
+			fakeResponse{stdout: "To /src/remote.git\n!\trefs/heads/main:refs/heads/main\t[rejected] (fetch first)\nDone\n",
+				exitStatus: 1},
+			fakeResponse{})
+		_, err := RunSquashMergeWorkflow(createScriptedExecCommand(&calls, responses...),
+			SquashMergeWorkflow{Source: "feat", Target: "main", Remote: "origin", Message: "Feature"})
+		if exitCodeOf(err) != 1 {
+			t.Fatalf("Expected the push's exit status 1, but received %v", err)
+		}
+		expectedCmds := [][]string{
+			{"git", "update-ref", "refs/heads/feat", testSHA1},
+			{"git", "update-ref", "-d", "refs/heads/main"},
+			{"git", "checkout", "--quiet", "--force", "feat", "--"},
+		}
+		if !reflect.DeepEqual(calls[len(calls)-3:], expectedCmds) {
+			t.Fatalf("Expected %v last, but received %v", expectedCmds, calls)
+		}
+		if _, err := os.Stat(filepath.Join(gitDir, "SQUASH_MSG")); !os.IsNotExist(err) {
+			t.Fatalf("Expected SQUASH_MSG to be removed, but received %v", err)
+		}
+	}
+}
+
+func TestSquashMergePreflight(t *testing.T) {
+	setup()
+	gitDir, err := ioutil.TempDir("", "squashmerge")
+	if err != nil {
+		t.Fatalf("Expected nil error, but received %v", err)
+	}
+	defer os.RemoveAll(gitDir)
+	{
+		calls := [][]string{}
+		responses := squashMergePreflightResponses(gitDir)
+		responses[2] = fakeResponse{stdout: " M a.txt\x00"}
+		_, err := RunSquashMergeWorkflow(createScriptedExecCommand(&calls, responses...),
+			SquashMergeWorkflow{Target: "main"})
+		if !errors.Is(err, ErrPreflightFailed) || len(calls) != 3 {
+			t.Fatalf("Expected ErrPreflightFailed after checking status, but received %v, %v", err, calls)
+		}
+	}
+	{ // An unpushed commit on the target branch would be pushed along with the squashed commit.
+		calls := [][]string{}
+		responses := squashMergePreflightResponses(gitDir)
+		responses[5] = fakeResponse{stdout: testSHA1 + "\n"}
+		responses = append(responses[:9], fakeResponse{exitStatus: 1})
+		_, err := RunSquashMergeWorkflow(createScriptedExecCommand(&calls, responses...),
+			SquashMergeWorkflow{Target: "main"})
+		if !errors.Is(err, ErrPreflightFailed) || len(calls) != 10 {
+			t.Fatalf("Expected ErrPreflightFailed after checking main, but received %v, %v", err, calls)
+		}
+	}
+	{
+		calls := [][]string{}
+		if err := ioutil.WriteFile(filepath.Join(gitDir, "MERGE_HEAD"), []byte(testSHA1+"\n"), 0600); err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		_, err := RunSquashMergeWorkflow(createScriptedExecCommand(&calls, squashMergePreflightResponses(gitDir)...),
+			SquashMergeWorkflow{Target: "main"})
+		if !errors.Is(err, ErrPreflightFailed) || len(calls) != 2 {
+			t.Fatalf("Expected ErrPreflightFailed before checking status, but received %v, %v", err, calls)
+		}
+	}
+	{
+		calls := [][]string{}
+		_, err := RunSquashMergeWorkflow(createScriptedExecCommand(&calls, squashMergePreflightResponses(gitDir)...),
+			SquashMergeWorkflow{Target: "feat"})
+		if !errors.Is(err, ErrPreflightFailed) {
+			t.Fatalf("Expected ErrPreflightFailed, but received %v", err)
+		}
+		calls = [][]string{}
+		_, err = RunSquashMergeWorkflow(createScriptedExecCommand(&calls, squashMergePreflightResponses(gitDir)...),
+			SquashMergeWorkflow{Target: "--force"})
+		if !errors.Is(err, ErrUnsafeArgument) {
+			t.Fatalf("Expected ErrUnsafeArgument, but received %v", err)
+		}
+	}
+}
+
+func TestRunSquashMergeWorkflowInDir(t *testing.T) {
+	// Runs the workflow with real git through a controller for another directory than the test's, which is itself
+	// inside a repository, so that checks made in the wrong directory would fail or succeed wrongly.
+	setup()
+	root, err := ioutil.TempDir("", "squashmerge")
+	if err != nil {
+		t.Fatalf("Expected nil error, but received %v", err)
+	}
+	defer os.RemoveAll(root)
+	// The user's own configuration, such as commit signing, must not affect the test.
+	env := map[string]string{"HOME": root, "GIT_CONFIG_NOSYSTEM": "1", "GIT_AUTHOR_NAME": "Dev",
+		"GIT_AUTHOR_EMAIL": "dev@example.com", "GIT_COMMITTER_NAME": "Dev", "GIT_COMMITTER_EMAIL": "dev@example.com"}
+	for variable, value := range env {
+		if old, ok := os.LookupEnv(variable); ok {
+			defer os.Setenv(variable, old)
+		} else {
+			defer os.Unsetenv(variable)
+		}
+		os.Setenv(variable, value)
+	}
+	remote, work := filepath.Join(root, "remote.git"), filepath.Join(root, "work")
+	git := func(dir string, args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	git(root, "init", "--quiet", "--bare", remote)
+	git(root, "init", "--quiet", work)
+	git(work, "checkout", "--quiet", "-b", "main")
+	git(work, "remote", "add", "origin", remote)
+	git(work, "commit", "--quiet", "--allow-empty", "-m", "Base")
+	git(work, "push", "--quiet", "origin", "main")
+	git(work, "checkout", "--quiet", "-b", "feat")
+	for _, name := range []string{"a", "b"} {
+		if err := ioutil.WriteFile(filepath.Join(work, name), []byte(name+"\n"), 0644); err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		git(work, "add", name)
+		git(work, "commit", "--quiet", "-m", "Add "+name)
+	}
+	controller := MakeControllerForDir(work)
+
+	// Hooks rejecting the squashed commit or the push are rolled back, leaving no squash message for the next commit.
+	feat := git(work, "rev-parse", "feat")
+	for _, hook := range []string{filepath.Join(work, ".git", "hooks", "pre-commit"),
+		filepath.Join(remote, "hooks", "pre-receive")} {
+		if err := ioutil.WriteFile(hook, []byte("#!/bin/sh\nexit 1\n"), 0755); err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		if _, err := controller.RunSquashMergeWorkflow(SquashMergeWorkflow{Target: "main"}); err == nil {
+			t.Fatalf("Expected %s to fail the workflow", filepath.Base(hook))
+		}
+		head := git(work, "symbolic-ref", "HEAD")
+		if head != "refs/heads/feat" || git(work, "rev-parse", "feat") != feat {
+			t.Fatalf("Expected feat to be restored, but HEAD is %s at %s", head, git(work, "rev-parse", "HEAD"))
+		}
+		if _, err := os.Stat(filepath.Join(work, ".git", "SQUASH_MSG")); !os.IsNotExist(err) {
+			t.Fatalf("Expected SQUASH_MSG to be removed, but received %v", err)
+		}
+		if err := os.Remove(hook); err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+	}
+
+	commit, err := controller.RunSquashMergeWorkflow(SquashMergeWorkflow{Target: "main"})
+	if err != nil {
+		t.Fatalf("Expected nil error, but received %v", err)
+	}
+	if pushed := git(remote, "rev-parse", "main"); pushed != string(commit) {
+		t.Fatalf("Expected %s to be pushed, but main is at %s", commit, pushed)
+	}
+	if message := git(work, "log", "-1", "--format=%B"); message != "Squash merge branch 'feat' into main\n\n"+
+		"* Add a\n* Add b" {
+		t.Fatalf("Unexpected message %q", message)
+	}
+}
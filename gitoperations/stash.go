@@ -0,0 +1,222 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrNoLocalChanges is returned by StashPush when there was nothing to stash.
+var ErrNoLocalChanges = errors.New("No local changes to save")
+
+// StashPushOptions controls StashPush.  The zero value stashes every change to tracked files.
+type StashPushOptions struct {
+	Message          string
+	IncludeUntracked bool
+	// KeepIndex leaves the changes which are staged in place, as well as stashing them.
+	KeepIndex bool
+	// Pathspecs limits the stash to matching paths.
+	Pathspecs []string
+}
+
+// StashEntry describes a stash.  Ref, such as stash@{0}, shifts as stashes are pushed and dropped; Commit does not.
+type StashEntry struct {
+	Index   int
+	Ref     string
+	Commit  ObjectID
+	Branch  string
+	Message string
+}
+
+// FileChange is a changed path as reported by --name-status.  Status is the single letter git uses, such as "M" for
+// modified or "R" for renamed, and OldPath is only set for renames and copies.
+type FileChange struct {
+	Status  string
+	Path    string
+	OldPath string
+}
+
+func StashPush(exec Executor, opts StashPushOptions) (ObjectID, error) {
+	// Returns the commit of the new stash, or ErrNoLocalChanges when git had nothing to stash and created no stash.
+	before, err := getStashCommit(exec)
+	if err != nil {
+		return "", err
+	}
+	cmdArr := []string{"git", "stash", "push", "--quiet"}
+	if len(opts.Message) != 0 {
+		cmdArr = append(cmdArr, "--message="+opts.Message)
+	}
+	if opts.IncludeUntracked {
+		cmdArr = append(cmdArr, "--include-untracked")
+	}
+	if opts.KeepIndex {
+		cmdArr = append(cmdArr, "--keep-index")
+	}
+	if len(opts.Pathspecs) != 0 {
+		cmdArr = append(append(cmdArr, "--"), opts.Pathspecs...)
+	}
+	if _, err := runAndGetOutput(exec, cmdArr); err != nil {
+		return "", err
+	}
+	after, err := getStashCommit(exec)
+	if err != nil {
+		return "", err
+	}
+	if after == before {
+		return "", ErrNoLocalChanges
+	}
+	return after, nil
+}
+
+// getStashCommit returns the commit of the most recent stash, or "" when there are no stashes.
+func getStashCommit(exec Executor) (ObjectID, error) {
+	cmdArr := []string{"git", "rev-parse", "--quiet", "--verify", "refs/stash"}
+	out, err := runAndGetOutput(exec, cmdArr)
+	if err != nil {
+		// rev-parse --quiet --verify exits 1 when the ref does not exist.
+		if exitCodeOf(err) == 1 {
+			return "", nil
+		}
+		return "", err
+	}
+	return ParseObjectID(string(out))
+}
+
+var reForStashSubject = regexp.MustCompile(`^(?:WIP on|On) ([^:]*): (.*)$`)
+
+func ListStashes(exec Executor) ([]StashEntry, error) {
+	// Each line holds the reflog selector, commit and reflog subject, separated by NUL.  The subject looks like
+	// "On main: message" for stashes pushed with a message, and "WIP on main: 7041aaf subject of HEAD" otherwise.
+	cmdArr := []string{"git", "stash", "list", "--format=%gd%x00%H%x00%gs"}
+	out, err := runAndGetOutput(exec, cmdArr)
+	if err != nil {
+		return nil, err
+	}
+	stashes := []StashEntry{}
+	scanner := scanAndSplit(out)
+	for scanner.Scan() {
+		line := scanner.Text()
+		fields := strings.SplitN(line, "\x00", 3)
+		if len(fields) != 3 || !strings.HasPrefix(fields[0], "stash@{") || !strings.HasSuffix(fields[0], "}") {
+			return nil, errors.New("Unrecognized stash list output: " + line)
+		}
+		index, err := strconv.Atoi(fields[0][len("stash@{") : len(fields[0])-1])
+		if err != nil {
+			return nil, errors.New("Unrecognized stash list output: " + line)
+		}
+		commit, err := ParseObjectID(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		stash := StashEntry{Index: index, Ref: fields[0], Commit: commit, Message: fields[2]}
+		if matched := reForStashSubject.FindStringSubmatch(fields[2]); matched != nil {
+			stash.Branch, stash.Message = matched[1], matched[2]
+		}
+		stashes = append(stashes, stash)
+	}
+	return stashes, nil
+}
+
+func StashApply(exec Executor, ref string, restoreIndex bool) ([]Conflict, error) {
+	// Applies the stash, the latest when ref is empty, keeping it.  When applying stops on conflicts they are returned
+	// together with an error matching ErrConflict.
+	return stashApplyOrPop(exec, "apply", ref, restoreIndex)
+}
+
+func StashPop(exec Executor, ref string, restoreIndex bool) ([]Conflict, error) {
+	// Like StashApply, but drops the stash once it applies cleanly.  Git keeps the stash when applying conflicts.
+	return stashApplyOrPop(exec, "pop", ref, restoreIndex)
+}
+
+func stashApplyOrPop(exec Executor, subcommand string, ref string, restoreIndex bool) ([]Conflict, error) {
+	if err := checkArguments(ref); err != nil {
+		return nil, err
+	}
+	cmdArr := []string{"git", "stash", subcommand, "--quiet"}
+	if restoreIndex {
+		cmdArr = append(cmdArr, "--index")
+	}
+	if len(ref) != 0 {
+		cmdArr = append(cmdArr, "--end-of-options", ref)
+	}
+	_, err := runAndGetOutput(exec, cmdArr)
+	if err == nil {
+		return nil, nil
+	}
+	conflicts, listErr := ListConflicts(exec)
+	if listErr != nil || len(conflicts) == 0 {
+		return nil, err
+	}
+	return conflicts, fmt.Errorf("%w: %v", ErrConflict, err)
+}
+
+func StashDrop(exec Executor, ref string) error {
+	if err := checkArguments(ref); err != nil {
+		return err
+	}
+	cmdArr := []string{"git", "stash", "drop", "--quiet"}
+	if len(ref) != 0 {
+		cmdArr = append(cmdArr, "--end-of-options", ref)
+	}
+	_, err := runAndGetOutput(exec, cmdArr)
+	return err
+}
+
+// The minimum git version which supports 'git stash show --include-untracked'.
+const stashShowUntrackedMajor, stashShowUntrackedMinor = 2, 32
+
+func StashShow(exec Executor, ref string) ([]FileChange, error) {
+	// Summarizes the files a stash changes, the latest when ref is empty.  Untracked files saved in the stash are
+	// included with git 2.32 or newer.
+	if err := checkArguments(ref); err != nil {
+		return nil, err
+	}
+	version, err := GetGitVersion(exec)
+	if err != nil {
+		return nil, err
+	}
+	cmdArr := []string{"git", "stash", "show", "--name-status", "-z"}
+	if version.AtLeast(stashShowUntrackedMajor, stashShowUntrackedMinor) {
+		cmdArr = append(cmdArr, "--include-untracked")
+	}
+	if len(ref) != 0 {
+		cmdArr = append(cmdArr, "--end-of-options", ref)
+	}
+	out, err := runAndGetOutput(exec, cmdArr)
+	if err != nil {
+		return nil, err
+	}
+	return parseNameStatus(out)
+}
+
+func parseNameStatus(output []byte) ([]FileChange, error) {
+	// With -z each change is a status followed by its path, or for renames and copies by a similarity score and both
+	// paths, all NUL terminated:
+	// M NUL path NUL R100 NUL old NUL new NUL
+	changes := []FileChange{}
+	records := splitNul(output)
+	for i := 0; i < len(records); i++ {
+		status := records[i]
+		if len(status) == 0 || i+1 >= len(records) {
+			return nil, errors.New("Unrecognized name-status output: " + status)
+		}
+		change := FileChange{Status: status[:1]}
+		if change.Status == "R" || change.Status == "C" {
+			if i+2 >= len(records) {
+				return nil, errors.New("Unrecognized name-status output: " + status)
+			}
+			change.OldPath, change.Path = records[i+1], records[i+2]
+			i += 2
+		} else {
+			change.Path = records[i+1]
+			i++
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
@@ -0,0 +1,178 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestStashPush(t *testing.T) {
+	setup()
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls,
+			fakeResponse{exitStatus: 1},
+			fakeResponse{},
+			fakeResponse{stdout: "7aa13e35e5067ec251d4c398bfe863c4e3498968\n"})
+		stash, err := StashPush(mockGit, StashPushOptions{Message: "wip", IncludeUntracked: true, KeepIndex: true,
+			Pathspecs: []string{"src", "-odd"}})
+		if err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		if stash != "7aa13e35e5067ec251d4c398bfe863c4e3498968" {
+			t.Fatalf("Expected the new stash commit, but received %q", stash)
+		}
+		expectedCmd := []string{"git", "stash", "push", "--quiet", "--message=wip", "--include-untracked",
+			"--keep-index", "--", "src", "-odd"}
+		if !reflect.DeepEqual(calls[1], expectedCmd) {
+			t.Fatalf("Expected %v, but received %v", expectedCmd, calls[1])
+		}
+	}
+	{ // Git succeeds without creating a stash when there is nothing to stash.
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls,
+			fakeResponse{stdout: "7aa13e35e5067ec251d4c398bfe863c4e3498968\n"},
+			fakeResponse{},
+			fakeResponse{stdout: "7aa13e35e5067ec251d4c398bfe863c4e3498968\n"})
+		if _, err := StashPush(mockGit, StashPushOptions{}); err != ErrNoLocalChanges {
+			t.Fatalf("Expected ErrNoLocalChanges, but received %v", err)
+		}
+	}
+}
+
+func TestListStashes(t *testing.T) {
+	setup()
+	calls := [][]string{}
+	mockGit := createScriptedExecCommand(&calls, fakeResponse{stdout: "" +
+		"stash@{0}\x007aa13e35e5067ec251d4c398bfe863c4e3498968\x00On main: my msg\n" +
+		"stash@{1}\x000a1b2c3d4e5f60718293a4b5c6d7e8f901234567\x00WIP on topic: 7041aaf Fix: the thing\n"})
+	stashes, err := ListStashes(mockGit)
+	if err != nil {
+		t.Fatalf("Expected nil error, but received %v", err)
+	}
+	expected := []StashEntry{
+		{Index: 0, Ref: "stash@{0}", Commit: "7aa13e35e5067ec251d4c398bfe863c4e3498968", Branch: "main",
+			Message: "my msg"},
+		{Index: 1, Ref: "stash@{1}", Commit: "0a1b2c3d4e5f60718293a4b5c6d7e8f901234567", Branch: "topic",
+			Message: "7041aaf Fix: the thing"},
+	}
+	if !reflect.DeepEqual(stashes, expected) {
+		t.Fatalf("Expected %+v, but received %+v", expected, stashes)
+	}
+
+	mockGit = createScriptedExecCommand(&calls, fakeResponse{stdout: "refs/stash\x00abc\x00On main: x\n"})
+	if _, err := ListStashes(mockGit); err == nil {
+		t.Fatalf("Expected non-nil error")
+	}
+}
+
+func TestStashApplyAndPop(t *testing.T) {
+	setup()
+	{
+		calls := [][]string{}
+		conflicts, err := StashApply(createScriptedExecCommand(&calls, fakeResponse{}), "stash@{1}", true)
+		if err != nil || conflicts != nil {
+			t.Fatalf("Expected no conflicts and nil error, but received %v, %v", conflicts, err)
+		}
+		expectedCmd := []string{"git", "stash", "apply", "--quiet", "--index", "--end-of-options", "stash@{1}"}
+		if !reflect.DeepEqual(calls[0], expectedCmd) {
+			t.Fatalf("Expected %v, but received %v", expectedCmd, calls[0])
+		}
+	}
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls,
+			fakeResponse{stderr: "CONFLICT (content): Merge conflict in dir/both modified\n", exitStatus: 1},
+			fakeResponse{stdout: lsFilesUnmerged})
+		conflicts, err := StashPop(mockGit, "", false)
+		if !errors.Is(err, ErrConflict) {
+			t.Fatalf("Expected ErrConflict, but received %v", err)
+		}
+		if len(conflicts) != 2 || conflicts[0].Path != "dir/both modified" {
+			t.Fatalf("Expected the conflicts listed, but received %+v", conflicts)
+		}
+		expectedCmd := []string{"git", "stash", "pop", "--quiet"}
+		if !reflect.DeepEqual(calls[0], expectedCmd) {
+			t.Fatalf("Expected %v, but received %v", expectedCmd, calls[0])
+		}
+	}
+	{ // Failures which leave no conflicts are returned as they are.
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls,
+			fakeResponse{stderr: "untracked already exists, no checkout\n", exitStatus: 1},
+			fakeResponse{})
+		conflicts, err := StashPop(mockGit, "", false)
+		if err == nil || errors.Is(err, ErrConflict) || conflicts != nil {
+			t.Fatalf("Expected a non-conflict error, but received %v, %v", conflicts, err)
+		}
+	}
+	{
+		calls := [][]string{}
+		if _, err := StashApply(createScriptedExecCommand(&calls, fakeResponse{}), "--index", false); !errors.Is(err, ErrUnsafeArgument) {
+			t.Fatalf("Expected ErrUnsafeArgument, but received %v", err)
+		}
+	}
+}
+
+func TestStashDrop(t *testing.T) {
+	setup()
+	calls := [][]string{}
+	if err := StashDrop(createScriptedExecCommand(&calls, fakeResponse{}), "stash@{2}"); err != nil {
+		t.Fatalf("Expected nil error, but received %v", err)
+	}
+	expectedCmd := []string{"git", "stash", "drop", "--quiet", "--end-of-options", "stash@{2}"}
+	if !reflect.DeepEqual(calls[0], expectedCmd) {
+		t.Fatalf("Expected %v, but received %v", expectedCmd, calls[0])
+	}
+}
+
+func TestStashShow(t *testing.T) {
+	setup()
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls,
+			fakeResponse{stdout: "git version 2.39.5\n"},
+			fakeResponse{stdout: "M\x00f\x00R087\x00old name\x00new name\x00A\x00untracked\x00"})
+		changes, err := StashShow(mockGit, "")
+		if err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		expected := []FileChange{
+			{Status: "M", Path: "f"},
+			{Status: "R", Path: "new name", OldPath: "old name"},
+			{Status: "A", Path: "untracked"},
+		}
+		if !reflect.DeepEqual(changes, expected) {
+			t.Fatalf("Expected %+v, but received %+v", expected, changes)
+		}
+		expectedCmd := []string{"git", "stash", "show", "--name-status", "-z", "--include-untracked"}
+		if !reflect.DeepEqual(calls[1], expectedCmd) {
+			t.Fatalf("Expected %v, but received %v", expectedCmd, calls[1])
+		}
+	}
+	{ // Older versions of git cannot show untracked files.
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls,
+			fakeResponse{stdout: "git version 2.30.2\n"},
+			fakeResponse{stdout: "M\x00f\x00"})
+		if _, err := StashShow(mockGit, "stash@{1}"); err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		expectedCmd := []string{"git", "stash", "show", "--name-status", "-z", "--end-of-options", "stash@{1}"}
+		if !reflect.DeepEqual(calls[1], expectedCmd) {
+			t.Fatalf("Expected %v, but received %v", expectedCmd, calls[1])
+		}
+	}
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls,
+			fakeResponse{stdout: "git version 2.39.5\n"},
+			fakeResponse{stdout: "R100\x00only one\x00"})
+		if _, err := StashShow(mockGit, ""); err == nil {
+			t.Fatalf("Expected non-nil error")
+		}
+	}
+}
@@ -0,0 +1,259 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"errors"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SubmoduleStatus is the state of a submodule's checkout, as reported by 'git submodule status'.
+type SubmoduleStatus string
+
+const (
+	// SubmoduleUninitialized submodules have not been initialized or have no checkout.
+	SubmoduleUninitialized SubmoduleStatus = "uninitialized"
+	// SubmoduleUpToDate submodules have the recorded commit checked out.
+	SubmoduleUpToDate SubmoduleStatus = "up-to-date"
+	// SubmoduleOutOfDate submodules have a different commit than the recorded one checked out, for example after
+	// pulling the superproject without updating its submodules.
+	SubmoduleOutOfDate SubmoduleStatus = "out-of-date"
+	// SubmoduleConflicted submodules have merge conflicts in the superproject.
+	SubmoduleConflicted SubmoduleStatus = "conflicted"
+)
+
+// Submodule describes a submodule of the repository.  Path is relative to the top level of the superproject's working
+// tree.  Name, URL and Branch come from .gitmodules, and are empty for submodules it does not describe.
+type Submodule struct {
+	Name   string
+	Path   string
+	URL    string
+	Branch string
+	// RecordedCommit is the commit the superproject's index records for the submodule.  It is empty while the
+	// submodule is conflicted.
+	RecordedCommit ObjectID
+	// CheckedOutCommit is the submodule's HEAD, and is empty when the submodule is not initialized or conflicted.
+	CheckedOutCommit ObjectID
+	Status           SubmoduleStatus
+	// Modified is true when the submodule's working tree has uncommitted changes to tracked files, and Untracked when
+	// it has untracked files.
+	Modified  bool
+	Untracked bool
+}
+
+// Initialized reports whether the submodule has been initialized and checked out.
+func (submodule Submodule) Initialized() bool {
+	return submodule.Status == SubmoduleUpToDate || submodule.Status == SubmoduleOutOfDate
+}
+
+// SubmoduleUpdateOptions controls SubmoduleUpdate.
+type SubmoduleUpdateOptions struct {
+	// Init initializes submodules which have not been initialized yet, rather than skipping them.
+	Init      bool
+	Recursive bool
+	// Remote updates submodules to the latest commit of their remote tracking branch, which is the branch configured
+	// in .gitmodules or the remote's HEAD, instead of the commit the superproject records.
+	Remote bool
+	// Depth, when positive, makes shallow clones of submodules with the given number of commits.
+	Depth int
+	// Jobs, when positive, sets the number of submodules fetched and cloned in parallel.
+	Jobs int
+}
+
+var reForSubmoduleStatus = regexp.MustCompile(`^([ +\-U])([0-9a-f]+) (.+?)(?: \([^()]*\))?$`)
+
+func ListSubmodules(exec Executor) ([]Submodule, error) {
+	_, submodules, err := listSubmodules(exec)
+	return submodules, err
+}
+
+// listSubmodules returns the top level of the working tree along with its submodules.
+func listSubmodules(exec Executor) (string, []Submodule, error) {
+	// 'git submodule status' reports a line for each submodule, in index order, with the commit checked out in the
+	// submodule, or the recorded commit when it is not initialized, and the output of 'git describe' for it:
+	// <status> <commit> SP <path> [SP (<describe>)] LF
+	// where the status is a space when the recorded commit is checked out, - when it is not initialized, + when a
+	// different commit is checked out and U when conflicted, in which case the commit is all zeros.
+	// The commands run at the top level so that paths are relative to it.
+	out, err := runAndGetOutput(exec, []string{"git", "rev-parse", "--show-toplevel"})
+	if err != nil {
+		return "", nil, err
+	}
+	topLevel := strings.TrimSpace(string(out))
+	if out, err = runAndGetOutput(exec, []string{"git", "-C", topLevel, "submodule", "status"}); err != nil {
+		return "", nil, err
+	}
+	submodules := []Submodule{}
+	for _, line := range strings.Split(string(out), "\n") {
+		if len(line) == 0 {
+			continue
+		}
+		match := reForSubmoduleStatus.FindStringSubmatch(line)
+		if match == nil {
+			return "", nil, errors.New("Unrecognized submodule status output: " + line)
+		}
+		submodule := Submodule{Path: match[3]}
+		id, err := ParseObjectID(match[2])
+		if err != nil {
+			return "", nil, err
+		}
+		switch match[1] {
+		case " ":
+			submodule.Status, submodule.RecordedCommit, submodule.CheckedOutCommit = SubmoduleUpToDate, id, id
+		case "-":
+			submodule.Status, submodule.RecordedCommit = SubmoduleUninitialized, id
+		case "+":
+			// The recorded commit is read from the status of the superproject below.
+			submodule.Status, submodule.CheckedOutCommit = SubmoduleOutOfDate, id
+		case "U":
+			submodule.Status = SubmoduleConflicted
+		}
+		submodules = append(submodules, submodule)
+	}
+	if len(submodules) == 0 {
+		return topLevel, submodules, nil
+	}
+	if err := readGitmodules(exec, topLevel, submodules); err != nil {
+		return "", nil, err
+	}
+	if err := readSubmoduleChanges(exec, topLevel, submodules); err != nil {
+		return "", nil, err
+	}
+	return topLevel, submodules, nil
+}
+
+// readGitmodules fills in the names, URLs and branches .gitmodules gives for submodules.
+func readGitmodules(exec Executor, topLevel string, submodules []Submodule) error {
+	// Keys have the form submodule.<name>.<variable>, where the name may itself contain dots.
+	entries, err := ConfigGetRegexp(exec, ConfigFileScope(filepath.Join(topLevel, ".gitmodules")), `^submodule\.`)
+	if err != nil {
+		return err
+	}
+	type settings struct{ path, url, branch string }
+	byName := map[string]*settings{}
+	names := []string{}
+	for _, entry := range entries {
+		dot := strings.LastIndexByte(entry.Key, '.')
+		name, variable := strings.TrimPrefix(entry.Key[:dot], "submodule."), entry.Key[dot+1:]
+		if byName[name] == nil {
+			byName[name] = &settings{}
+			names = append(names, name)
+		}
+		switch variable {
+		case "path":
+			byName[name].path = entry.Value
+		case "url":
+			byName[name].url = entry.Value
+		case "branch":
+			byName[name].branch = entry.Value
+		}
+	}
+	for i := range submodules {
+		for _, name := range names {
+			if byName[name].path == submodules[i].Path {
+				submodules[i].Name, submodules[i].URL, submodules[i].Branch = name, byName[name].url,
+					byName[name].branch
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// readSubmoduleChanges fills in the recorded commits of out of date submodules and whether submodules have local
+// changes, from the status of the superproject.
+func readSubmoduleChanges(exec Executor, topLevel string, submodules []Submodule) error {
+	// Changed entries are reported as NUL terminated records; for ordinary changes and renames or copies:
+	// 1 <XY> <sub> <mH> <mI> <mW> <hH> <hI> <path>
+	// 2 <XY> <sub> <mH> <mI> <mW> <hH> <hI> <X><score> <path> NUL <original path>
+	// where <sub> is "S<c><m><u>" for submodules: c is C when the checked out commit differs from the recorded one, m
+	// is M when tracked files are modified and u is U when there are untracked files.  <hI> is the recorded commit.
+	// Unmerged entries start with "u" and are already reported as conflicted.
+	cmdArr := []string{"git", "-C", topLevel, "--literal-pathspecs", "status", "--porcelain=v2", "-z",
+		"--untracked-files=no", "--ignore-submodules=none", "--"}
+	byPath := map[string]*Submodule{}
+	for i := range submodules {
+		cmdArr = append(cmdArr, submodules[i].Path)
+		byPath[submodules[i].Path] = &submodules[i]
+	}
+	out, err := runAndGetOutput(exec, cmdArr)
+	if err != nil {
+		return err
+	}
+	records := splitNul(out)
+	for i := 0; i < len(records); i++ {
+		record := records[i]
+		if len(record) == 0 {
+			continue
+		}
+		var fields []string
+		switch record[0] {
+		case '1':
+			fields = strings.SplitN(record, " ", 9)
+		case '2':
+			i++ // Skip the original path.
+			if fields = strings.SplitN(record, " ", 10); len(fields) == 10 {
+				fields = append(fields[:8], fields[9])
+			}
+		default:
+			continue
+		}
+		if len(fields) != 9 {
+			return errors.New("Unrecognized status output: " + record)
+		}
+		submodule := byPath[fields[8]]
+		if submodule == nil || len(fields[2]) != 4 || fields[2][0] != 'S' {
+			continue
+		}
+		if submodule.Status == SubmoduleOutOfDate {
+			if submodule.RecordedCommit, err = ParseObjectID(fields[7]); err != nil {
+				return err
+			}
+		}
+		submodule.Modified = fields[2][2] == 'M'
+		submodule.Untracked = fields[2][3] == 'U'
+	}
+	return nil
+}
+
+func SubmoduleUpdate(exec Executor, paths []string, opts SubmoduleUpdateOptions) error {
+	// Checks out the recorded commit of each submodule, or only of those under paths when given, cloning and fetching
+	// as needed.
+	cmdArr := []string{"git", "submodule", "update", "--quiet"}
+	if opts.Init {
+		cmdArr = append(cmdArr, "--init")
+	}
+	if opts.Recursive {
+		cmdArr = append(cmdArr, "--recursive")
+	}
+	if opts.Remote {
+		cmdArr = append(cmdArr, "--remote")
+	}
+	if opts.Depth > 0 {
+		cmdArr = append(cmdArr, "--depth", strconv.Itoa(opts.Depth))
+	}
+	if opts.Jobs > 0 {
+		cmdArr = append(cmdArr, "--jobs", strconv.Itoa(opts.Jobs))
+	}
+	cmdArr = append(cmdArr, "--")
+	cmdArr = append(cmdArr, paths...)
+	_, err := runAndGetOutput(exec, cmdArr)
+	return err
+}
+
+func SubmoduleSync(exec Executor, paths []string, recursive bool) error {
+	// Copies the URLs in .gitmodules into the superproject's configuration and the submodules' origin remotes, after
+	// they have changed upstream.
+	cmdArr := []string{"git", "submodule", "sync", "--quiet"}
+	if recursive {
+		cmdArr = append(cmdArr, "--recursive")
+	}
+	cmdArr = append(cmdArr, "--")
+	cmdArr = append(cmdArr, paths...)
+	_, err := runAndGetOutput(exec, cmdArr)
+	return err
+}
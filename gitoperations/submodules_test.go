@@ -0,0 +1,117 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestListSubmodules(t *testing.T) {
+	setup()
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls,
+			fakeResponse{stdout: "/repo\n"},
+			fakeResponse{stdout: "" +
+				" 6268d48b7aabfa924dda8a8e9565395ffa052150 dir/sub two (v1-2-g6268d48)\n" +
+				"-6268d48b7aabfa924dda8a8e9565395ffa052150 lib\n" +
+				"+9b9ca25f4614cbbbe31b53f1b449ffec4f13cde4 vendor/x (v1)\n" +
+				"U0000000000000000000000000000000000000000 merged\n"},
+			fakeResponse{stdout: "" +
+				"submodule.my.sub.path\ndir/sub two\x00submodule.my.sub.url\nhttps://example.com/a.git\x00" +
+				"submodule.my.sub.branch\nmain\x00" +
+				"submodule.lib.path\nlib\x00submodule.lib.url\n../lib.git\x00" +
+				"submodule.x.path\nvendor/x\x00submodule.x.url\nhttps://example.com/x.git\x00"},
+			fakeResponse{stdout: "" +
+				"1 .M S.MU 160000 160000 160000 6268d48b7aabfa924dda8a8e9565395ffa052150 " +
+				"6268d48b7aabfa924dda8a8e9565395ffa052150 dir/sub two\x00" +
+				"1 .M SC.. 160000 160000 160000 d21351258870d3b79f60e9d1748506cbcb12bd2b " +
+				"d21351258870d3b79f60e9d1748506cbcb12bd2b vendor/x\x00" +
+				"u UU S... 160000 160000 160000 160000 7041aaf6b3ab17effd5c8cd7a4abfa3bdee2a988 " +
+				"6268d48b7aabfa924dda8a8e9565395ffa052150 9b9ca25f4614cbbbe31b53f1b449ffec4f13cde4 merged\x00"})
+		submodules, err := ListSubmodules(mockGit)
+		if err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		expected := []Submodule{
+			{Name: "my.sub", Path: "dir/sub two", URL: "https://example.com/a.git", Branch: "main",
+				RecordedCommit:   "6268d48b7aabfa924dda8a8e9565395ffa052150",
+				CheckedOutCommit: "6268d48b7aabfa924dda8a8e9565395ffa052150", Status: SubmoduleUpToDate,
+				Modified: true, Untracked: true},
+			{Name: "lib", Path: "lib", URL: "../lib.git", RecordedCommit: "6268d48b7aabfa924dda8a8e9565395ffa052150",
+				Status: SubmoduleUninitialized},
+			{Name: "x", Path: "vendor/x", URL: "https://example.com/x.git",
+				RecordedCommit:   "d21351258870d3b79f60e9d1748506cbcb12bd2b",
+				CheckedOutCommit: "9b9ca25f4614cbbbe31b53f1b449ffec4f13cde4", Status: SubmoduleOutOfDate},
+			{Path: "merged", Status: SubmoduleConflicted},
+		}
+		if !reflect.DeepEqual(submodules, expected) {
+			t.Fatalf("Expected %+v, but received %+v", expected, submodules)
+		}
+		if !submodules[0].Initialized() || submodules[1].Initialized() || !submodules[2].Initialized() ||
+			submodules[3].Initialized() {
+			t.Fatalf("Unexpected initialized states for %+v", submodules)
+		}
+		expectedCmds := [][]string{
+			{"git", "rev-parse", "--show-toplevel"},
+			{"git", "-C", "/repo", "submodule", "status"},
+			{"git", "config", "--file", "/repo/.gitmodules", "--null", "--get-regexp", "--", `^submodule\.`},
+			{"git", "-C", "/repo", "--literal-pathspecs", "status", "--porcelain=v2", "-z", "--untracked-files=no",
+				"--ignore-submodules=none", "--", "dir/sub two", "lib", "vendor/x", "merged"},
+		}
+		if !reflect.DeepEqual(calls, expectedCmds) {
+			t.Fatalf("Expected %v, but received %v", expectedCmds, calls)
+		}
+	}
+	{ // Without submodules .gitmodules and the status are not read.
+		calls := [][]string{}
+		submodules, err := ListSubmodules(createScriptedExecCommand(&calls,
+			fakeResponse{stdout: "/repo\n"},
+			fakeResponse{}))
+		if err != nil || len(submodules) != 0 || len(calls) != 2 {
+			t.Fatalf("Expected no submodules from 2 commands, but received %v, %v from %v", submodules, err, calls)
+		}
+	}
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls,
+			fakeResponse{stdout: "/repo\n"},
+			fakeResponse{stdout: "?6268d48b7aabfa924dda8a8e9565395ffa052150 lib\n"})
+		if _, err := ListSubmodules(mockGit); err == nil {
+			t.Fatalf("Expected non-nil error")
+		}
+	}
+}
+
+func TestSubmoduleUpdateAndSync(t *testing.T) {
+	setup()
+	calls := [][]string{}
+	mockGit := createScriptedExecCommand(&calls, fakeResponse{})
+	if err := SubmoduleUpdate(mockGit, nil, SubmoduleUpdateOptions{}); err != nil {
+		t.Fatalf("Expected nil error, but received %v", err)
+	}
+	err := SubmoduleUpdate(mockGit, []string{"lib", "-odd"},
+		SubmoduleUpdateOptions{Init: true, Recursive: true, Remote: true, Depth: 1, Jobs: 4})
+	if err != nil {
+		t.Fatalf("Expected nil error, but received %v", err)
+	}
+	if err := SubmoduleSync(mockGit, []string{"lib"}, true); err != nil {
+		t.Fatalf("Expected nil error, but received %v", err)
+	}
+	expectedCmds := [][]string{
+		{"git", "submodule", "update", "--quiet", "--"},
+		{"git", "submodule", "update", "--quiet", "--init", "--recursive", "--remote", "--depth", "1", "--jobs", "4",
+			"--", "lib", "-odd"},
+		{"git", "submodule", "sync", "--quiet", "--recursive", "--", "lib"},
+	}
+	if !reflect.DeepEqual(calls, expectedCmds) {
+		t.Fatalf("Expected %v, but received %v", expectedCmds, calls)
+	}
+
+	mockGit = createScriptedExecCommand(&calls, fakeResponse{stderr: "fatal: clone failed\n", exitStatus: 1})
+	if err := SubmoduleUpdate(mockGit, nil, SubmoduleUpdateOptions{Init: true}); exitCodeOf(err) != 1 {
+		t.Fatalf("Expected exit status 1, but received %v", err)
+	}
+}
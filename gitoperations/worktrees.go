@@ -0,0 +1,191 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"errors"
+	"strings"
+)
+
+// Worktree describes a working tree attached to the repository.  Branch is the full ref name of the checked out
+// branch, and is empty when HEAD is detached.
+type Worktree struct {
+	Path string
+	HEAD ObjectID
+	// Main is true for the repository's main working tree, which is always listed first.
+	Main           bool
+	Branch         string
+	Bare           bool
+	Detached       bool
+	Locked         bool
+	LockReason     string
+	Prunable       bool
+	PrunableReason string
+}
+
+// AddWorktreeOptions controls AddWorktree.  Without NewBranch or Detach the worktree checks out Commitish, which must
+// then name a branch which is not checked out elsewhere unless Force is set.
+type AddWorktreeOptions struct {
+	// Commitish is what the worktree checks out.  When empty git uses HEAD, or a branch named after the directory.
+	Commitish string
+	// NewBranch creates a branch of the given name at Commitish and checks it out.
+	NewBranch  string
+	Detach     bool
+	Force      bool
+	NoCheckout bool
+	// Lock locks the new worktree, recording LockReason when it is set.
+	Lock       bool
+	LockReason string
+}
+
+// The minimum git version which supports 'git worktree list -z'.
+const worktreeListNulMajor, worktreeListNulMinor = 2, 36
+
+func ListWorktrees(exec Executor) ([]Worktree, error) {
+	// Parses 'git worktree list --porcelain', which describes each worktree with one attribute per line and separates
+	// worktrees with an empty line:
+	// worktree /path/to/main
+	// HEAD 7041aaf6b3ab17effd5c8cd7a4abfa3bdee2a988
+	// branch refs/heads/main
+	//
+	// worktree /path/to/linked
+	// HEAD 7041aaf6b3ab17effd5c8cd7a4abfa3bdee2a988
+	// detached
+	// locked in use
+	// With git 2.36 or newer -z terminates lines with NUL instead, so that paths and reasons may contain newlines.
+	version, err := GetGitVersion(exec)
+	if err != nil {
+		return nil, err
+	}
+	cmdArr := []string{"git", "worktree", "list", "--porcelain"}
+	nul := version.AtLeast(worktreeListNulMajor, worktreeListNulMinor)
+	if nul {
+		cmdArr = append(cmdArr, "-z")
+	}
+	out, err := runAndGetOutput(exec, cmdArr)
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	if nul {
+		lines = splitNul(out)
+	} else {
+		lines = strings.Split(string(out), "\n")
+	}
+
+	worktrees := []Worktree{}
+	var current *Worktree
+	for _, line := range lines {
+		if len(line) == 0 {
+			current = nil
+			continue
+		}
+		attribute, value := line, ""
+		if space := strings.IndexByte(line, ' '); space >= 0 {
+			attribute, value = line[:space], line[space+1:]
+		}
+		if attribute == "worktree" {
+			worktrees = append(worktrees, Worktree{Path: value, Main: len(worktrees) == 0})
+			current = &worktrees[len(worktrees)-1]
+			continue
+		}
+		if current == nil {
+			return nil, errors.New("Unrecognized worktree list output: " + line)
+		}
+		switch attribute {
+		case "HEAD":
+			if current.HEAD, err = ParseObjectID(value); err != nil {
+				return nil, err
+			}
+		case "branch":
+			current.Branch = value
+		case "bare":
+			current.Bare = true
+		case "detached":
+			current.Detached = true
+		case "locked":
+			current.Locked, current.LockReason = true, value
+		case "prunable":
+			current.Prunable, current.PrunableReason = true, value
+		}
+	}
+	return worktrees, nil
+}
+
+func AddWorktree(exec Executor, path string, opts AddWorktreeOptions) error {
+	cmdArr := []string{"git", "worktree", "add", "--quiet"}
+	if len(opts.NewBranch) != 0 {
+		if err := checkArguments(opts.NewBranch); err != nil {
+			return err
+		}
+		cmdArr = append(cmdArr, "-b", opts.NewBranch)
+	}
+	if opts.Detach {
+		cmdArr = append(cmdArr, "--detach")
+	}
+	if opts.Force {
+		cmdArr = append(cmdArr, "--force")
+	}
+	if opts.NoCheckout {
+		cmdArr = append(cmdArr, "--no-checkout")
+	}
+	if opts.Lock {
+		cmdArr = append(cmdArr, "--lock")
+		if len(opts.LockReason) != 0 {
+			cmdArr = append(cmdArr, "--reason", opts.LockReason)
+		}
+	}
+	cmdArr = append(cmdArr, "--", path)
+	if len(opts.Commitish) != 0 {
+		cmdArr = append(cmdArr, opts.Commitish)
+	}
+	_, err := runAndGetOutput(exec, cmdArr)
+	return err
+}
+
+func RemoveWorktree(exec Executor, path string, force bool) error {
+	// Without force git refuses to remove worktrees which have local modifications or are locked.  Removing a locked
+	// worktree takes --force twice.
+	cmdArr := []string{"git", "worktree", "remove"}
+	if force {
+		cmdArr = append(cmdArr, "--force", "--force")
+	}
+	cmdArr = append(cmdArr, "--", path)
+	_, err := runAndGetOutput(exec, cmdArr)
+	return err
+}
+
+func MoveWorktree(exec Executor, path string, newPath string) error {
+	cmdArr := []string{"git", "worktree", "move", "--", path, newPath}
+	_, err := runAndGetOutput(exec, cmdArr)
+	return err
+}
+
+func LockWorktree(exec Executor, path string, reason string) error {
+	// Locking keeps a worktree, for example one on removable storage, from being pruned, moved or removed.
+	cmdArr := []string{"git", "worktree", "lock"}
+	if len(reason) != 0 {
+		cmdArr = append(cmdArr, "--reason", reason)
+	}
+	cmdArr = append(cmdArr, "--", path)
+	_, err := runAndGetOutput(exec, cmdArr)
+	return err
+}
+
+func UnlockWorktree(exec Executor, path string) error {
+	cmdArr := []string{"git", "worktree", "unlock", "--", path}
+	_, err := runAndGetOutput(exec, cmdArr)
+	return err
+}
+
+func PruneWorktrees(exec Executor, expire string) error {
+	// Removes the administrative files of worktrees whose directories have been deleted.  When expire is set, for
+	// example "3.days.ago", only worktrees missing for longer than that are pruned.
+	cmdArr := []string{"git", "worktree", "prune"}
+	if len(expire) != 0 {
+		cmdArr = append(cmdArr, "--expire="+expire)
+	}
+	_, err := runAndGetOutput(exec, cmdArr)
+	return err
+}
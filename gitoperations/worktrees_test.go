@@ -0,0 +1,113 @@
+// Copyright Amazon.com, Inc. or its affiliates. All Rights Reserved.
+// SPDX-License-Identifier: Apache-2.0
+
+package gitoperations
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var expectedWorktrees = []Worktree{
+	{Path: "/src/repo", HEAD: "7041aaf6b3ab17effd5c8cd7a4abfa3bdee2a988", Main: true, Branch: "refs/heads/main"},
+	{Path: "/src/wt one", HEAD: "7041aaf6b3ab17effd5c8cd7a4abfa3bdee2a988", Branch: "refs/heads/topic"},
+	{Path: "/src/wt2", HEAD: "7041aaf6b3ab17effd5c8cd7a4abfa3bdee2a988", Detached: true, Locked: true,
+		LockReason: "in use"},
+	{Path: "/gone", HEAD: "0a1b2c3d4e5f60718293a4b5c6d7e8f901234567", Detached: true, Locked: true, Prunable: true,
+		PrunableReason: "gitdir file points to non-existent location"},
+}
+
+func TestListWorktrees(t *testing.T) {
+	setup()
+	lines := []string{
+		"worktree /src/repo", "HEAD 7041aaf6b3ab17effd5c8cd7a4abfa3bdee2a988", "branch refs/heads/main", "",
+		"worktree /src/wt one", "HEAD 7041aaf6b3ab17effd5c8cd7a4abfa3bdee2a988", "branch refs/heads/topic", "",
+		"worktree /src/wt2", "HEAD 7041aaf6b3ab17effd5c8cd7a4abfa3bdee2a988", "detached", "locked in use", "",
+		"worktree /gone", "HEAD 0a1b2c3d4e5f60718293a4b5c6d7e8f901234567", "detached", "locked",
+		"prunable gitdir file points to non-existent location", "",
+	}
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls,
+			fakeResponse{stdout: "git version 2.39.5\n"},
+			fakeResponse{stdout: strings.Join(lines, "\x00") + "\x00"})
+		worktrees, err := ListWorktrees(mockGit)
+		if err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		if !reflect.DeepEqual(worktrees, expectedWorktrees) {
+			t.Fatalf("Expected %+v, but received %+v", expectedWorktrees, worktrees)
+		}
+		expectedCmd := []string{"git", "worktree", "list", "--porcelain", "-z"}
+		if !reflect.DeepEqual(calls[1], expectedCmd) {
+			t.Fatalf("Expected %v, but received %v", expectedCmd, calls[1])
+		}
+	}
+	{ // Older versions of git terminate lines with newlines.
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls,
+			fakeResponse{stdout: "git version 2.30.2\n"},
+			fakeResponse{stdout: strings.Join(lines, "\n") + "\n"})
+		worktrees, err := ListWorktrees(mockGit)
+		if err != nil {
+			t.Fatalf("Expected nil error, but received %v", err)
+		}
+		if !reflect.DeepEqual(worktrees, expectedWorktrees) {
+			t.Fatalf("Expected %+v, but received %+v", expectedWorktrees, worktrees)
+		}
+		if len(calls[1]) != 4 {
+			t.Fatalf("Expected no -z, but received %v", calls[1])
+		}
+	}
+	{
+		calls := [][]string{}
+		mockGit := createScriptedExecCommand(&calls,
+			fakeResponse{stdout: "git version 2.39.5\n"},
+			fakeResponse{stdout: "HEAD 7041aaf6b3ab17effd5c8cd7a4abfa3bdee2a988\x00"})
+		if _, err := ListWorktrees(mockGit); err == nil {
+			t.Fatalf("Expected non-nil error")
+		}
+	}
+}
+
+func TestWorktreeEditing(t *testing.T) {
+	setup()
+	type cmd struct {
+		f        func(exec Executor) error
+		expected []string
+	}
+	functions := []cmd{
+		{func(exec Executor) error {
+			return AddWorktree(exec, "../build", AddWorktreeOptions{Commitish: "origin/main", NewBranch: "build",
+				Lock: true, LockReason: "ci job 42"})
+		}, []string{"git", "worktree", "add", "--quiet", "-b", "build", "--lock", "--reason", "ci job 42", "--",
+			"../build", "origin/main"}},
+		{func(exec Executor) error {
+			return AddWorktree(exec, "../scratch", AddWorktreeOptions{Detach: true, Force: true, NoCheckout: true})
+		}, []string{"git", "worktree", "add", "--quiet", "--detach", "--force", "--no-checkout", "--", "../scratch"}},
+		{func(exec Executor) error { return RemoveWorktree(exec, "../build", true) },
+			[]string{"git", "worktree", "remove", "--force", "--force", "--", "../build"}},
+		{func(exec Executor) error { return MoveWorktree(exec, "../build", "../build2") },
+			[]string{"git", "worktree", "move", "--", "../build", "../build2"}},
+		{func(exec Executor) error { return LockWorktree(exec, "../build", "") },
+			[]string{"git", "worktree", "lock", "--", "../build"}},
+		{func(exec Executor) error { return UnlockWorktree(exec, "../build") },
+			[]string{"git", "worktree", "unlock", "--", "../build"}},
+		{func(exec Executor) error { return PruneWorktrees(exec, "3.days.ago") },
+			[]string{"git", "worktree", "prune", "--expire=3.days.ago"}},
+	}
+	for _, fn := range functions {
+		calls := [][]string{}
+		if err := fn.f(createScriptedExecCommand(&calls, fakeResponse{})); err != nil {
+			t.Errorf("Expected nil error, but received %v", err)
+		}
+		if !reflect.DeepEqual(calls[0], fn.expected) {
+			t.Errorf("Expected %v, but received %v", fn.expected, calls[0])
+		}
+		calls = [][]string{}
+		if err := fn.f(createScriptedExecCommand(&calls, fakeResponse{exitStatus: 128})); exitCodeOf(err) != 128 {
+			t.Errorf("Expected exit status 128, but received %v", err)
+		}
+	}
+}